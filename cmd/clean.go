@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/oneclickvirt/disktest/disk"
+)
+
+type cleanOptions struct {
+	paths     []string
+	yes       bool
+	discovery discoveryFlags
+	filter    disk.DiscoveryFilter
+	unitFlags unitFlags
+	units     disk.UnitFormat
+}
+
+type pathList []string
+
+func (list *pathList) String() string { return strings.Join(*list, ",") }
+
+func (list *pathList) Set(value string) error {
+	if value = strings.TrimSpace(value); value == "" {
+		return fmt.Errorf("path must not be empty")
+	}
+	*list = append(*list, value)
+	return nil
+}
+
+func newCleanFlagSet(opts *cleanOptions, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("disktest clean", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Var((*pathList)(&opts.paths), "p", "Additional directory to scan; may be repeated")
+	fs.BoolVar(&opts.yes, "y", false, "Remove the artifacts without asking for confirmation")
+	addDiscoveryFlags(fs, &opts.discovery)
+	addUnitFlags(fs, &opts.unitFlags)
+	return fs
+}
+
+func parseCleanCLI(args []string) (cleanOptions, error) {
+	opts := cleanOptions{}
+	fs := newCleanFlagSet(&opts, io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() != 0 {
+		return opts, fmt.Errorf("unexpected positional arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	units, err := opts.unitFlags.format()
+	if err != nil {
+		return opts, err
+	}
+	opts.units = units
+	filter, err := opts.discovery.filter()
+	opts.filter = filter
+	return opts, err
+}
+
+// runClean lists leftover disktest temporary files with their sizes and
+// removes them after confirmation. It returns the process exit code.
+func runClean(opts cleanOptions, input io.Reader, output io.Writer) int {
+	artifacts := disk.FindArtifacts(disk.ArtifactSearchPathsWithFilter(opts.filter, opts.paths...))
+	if len(artifacts) == 0 {
+		fmt.Fprintln(output, "No disktest artifacts found.")
+		return 0
+	}
+	var total int64
+	for _, artifact := range artifacts {
+		total += artifact.Size
+		fmt.Fprintf(output, "%12s  %s\n", opts.units.Bytes(float64(artifact.Size)), artifact.Path)
+	}
+	fmt.Fprintf(output, "%d artifacts, %s in total (%s units).\n", len(artifacts), opts.units.Bytes(float64(total)), opts.units)
+	if !opts.yes {
+		fmt.Fprint(output, "Remove them? [y/N] ")
+		answer, _ := bufio.NewReader(input).ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		if answer != "y" && answer != "yes" {
+			fmt.Fprintln(output, "Nothing removed.")
+			return 0
+		}
+	}
+	removed, err := disk.RemoveArtifacts(artifacts)
+	fmt.Fprintf(output, "Removed %d of %d artifacts.\n", removed, len(artifacts))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, sanitizeErrorText(err.Error()))
+		return 1
+	}
+	return 0
+}
@@ -2,9 +2,15 @@ package main
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/oneclickvirt/disktest/disk"
 )
 
 func TestParseCLIOptions(t *testing.T) {
@@ -67,3 +73,312 @@ func TestCLIActionPrioritizesHelpAndVersion(t *testing.T) {
 		t.Fatalf("version action = %q", got)
 	}
 }
+
+func TestParseCLICapacityOptions(t *testing.T) {
+	opts, err := parseCLI([]string{"-capacity", "-fraction", "0.25", "-p", "/mnt/usb"})
+	if err != nil {
+		t.Fatalf("parseCLI returned error: %v", err)
+	}
+	if selectCLIAction(opts) != "capacity" || opts.fraction != 0.25 || opts.path != "/mnt/usb" {
+		t.Fatalf("unexpected capacity options: %#v", opts)
+	}
+	for _, args := range [][]string{
+		{"-capacity", "-fraction", "0"},
+		{"-capacity", "-fraction", "1.5"},
+		{"-capacity", "-json"},
+		{"-fraction", "0.5"},
+	} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("expected arguments %v to be rejected", args)
+		}
+	}
+}
+
+func TestParseCLIScanOptions(t *testing.T) {
+	opts, err := parseCLI([]string{"-scan", "-p", "/dev/sdb", "-allow-device", "-chunk", "4194304"})
+	if err != nil {
+		t.Fatalf("parseCLI returned error: %v", err)
+	}
+	if selectCLIAction(opts) != "scan" || !opts.allowDevice || opts.chunkBytes != 4194304 {
+		t.Fatalf("unexpected scan options: %#v", opts)
+	}
+	for _, args := range [][]string{
+		{"-scan"},
+		{"-scan", "-p", "/img", "-chunk", "512"},
+		{"-scan", "-capacity", "-p", "/img"},
+		{"-allow-device"},
+	} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("expected arguments %v to be rejected", args)
+		}
+	}
+}
+
+func TestParseCLIOutputFormats(t *testing.T) {
+	for _, args := range [][]string{
+		{"-format", "csv"},
+		{"-json", "-format", "junit"},
+		{"-deep", "-format", "json"},
+	} {
+		if _, err := parseCLI(args); err != nil {
+			t.Fatalf("arguments %v returned %v", args, err)
+		}
+	}
+	for _, args := range [][]string{
+		{"-format", "yaml"},
+		{"-format", "json"},
+		{"-capacity", "-format", "csv"},
+	} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("expected arguments %v to be rejected", args)
+		}
+	}
+}
+
+func TestParseCLITextfileDefaultsToPrometheus(t *testing.T) {
+	opts, err := parseCLI([]string{"-json", "-textfile", "/var/lib/node_exporter/disktest.prom"})
+	if err != nil || opts.format != "prometheus" {
+		t.Fatalf("textfile options = %#v err=%v", opts, err)
+	}
+	if _, err := parseCLI([]string{"-json", "-format", "json", "-textfile", "/tmp/out"}); err == nil {
+		t.Fatal("expected json textfile to be rejected")
+	}
+	for _, args := range [][]string{{"-textfile", "/tmp/out"}, {"-format", "openmetrics"}, {"-m", "dd", "-format", "prometheus"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted a metrics format for a legacy run", args)
+		}
+	}
+	if opts, err := parseCLI([]string{"-format", "csv", "-textfile", "/tmp/out"}); err != nil || opts.format != "csv" {
+		t.Fatalf("legacy csv textfile = %+v err=%v", opts, err)
+	}
+}
+
+func TestSignalContextCancelsOnInterrupt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupt cannot be sent to the current process on Windows")
+	}
+	ctx, stop := signalContext()
+	defer stop()
+	process, _ := os.FindProcess(os.Getpid())
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("interrupt did not cancel the run context")
+	}
+}
+
+func TestParseCleanCLI(t *testing.T) {
+	opts, err := parseCleanCLI([]string{"-p", "/data", "-p", "/mnt/disk", "-y"})
+	if err != nil || !opts.yes || len(opts.paths) != 2 || opts.paths[1] != "/mnt/disk" {
+		t.Fatalf("unexpected clean options %#v err=%v", opts, err)
+	}
+	for _, args := range [][]string{{"-p", " "}, {"extra"}, {"-units", "jedec"}} {
+		if _, err := parseCleanCLI(args); err == nil {
+			t.Fatalf("parseCleanCLI(%q) accepted invalid input", args)
+		}
+	}
+	opts, err = parseCleanCLI([]string{"-units", "iec", "-precision", "1"})
+	if err != nil || opts.units.Bytes(3<<29) != "1.5 GiB" {
+		t.Fatalf("clean units = %+v err=%v", opts.units, err)
+	}
+	if opts, err := parseCleanCLI(nil); err != nil || opts.units.Bytes(3<<29) != "1.61 GB" {
+		t.Fatalf("clean default units = %+v err=%v", opts.units, err)
+	}
+}
+
+func TestParseCLILegacyFioTuning(t *testing.T) {
+	opts, err := parseCLI([]string{"-fio-bs", "4K, 1m", "-fio-runtime", "5s", "-fio-iodepth", "8", "-fio-numjobs", "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(opts.fioBlockSizeList, ",") != "4k,1m" || opts.fioRuntime != 5*time.Second || opts.fioIODepth != 8 || opts.fioNumJobs != 1 {
+		t.Fatalf("unexpected fio tuning: %#v", opts)
+	}
+	for _, args := range [][]string{
+		{"-fio-bs", "4x"},
+		{"-fio-runtime", "500ms"},
+		{"-fio-iodepth", "2048"},
+		{"-fio-bs", "0"},
+		{"-fio-bs", "4k,0k"},
+		{"-fio-bs", ","},
+		{"-fio-runtime", "0"},
+		{"-fio-iodepth", "0"},
+		{"-fio-numjobs", "0"},
+		{"-m", "dd", "-fio-numjobs", "4"},
+		{"-json", "-fio-bs", "4k"},
+		{"-capacity", "-fio-runtime", "5s"},
+	} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted invalid fio tuning", args)
+		}
+	}
+}
+
+func TestParseCLIDDTests(t *testing.T) {
+	opts, err := parseCLI([]string{"-m", "dd", "-dd-tests", "4k:50M,1M:500M"})
+	if err != nil || len(opts.ddTests) != 2 || opts.ddTests[0].TotalSize != "50M" {
+		t.Fatalf("unexpected dd tests %#v err=%v", opts.ddTests, err)
+	}
+	for _, args := range [][]string{{"-dd-tests", "4k"}, {"-m", "fio", "-dd-tests", "4k:50M"}, {"-dd-tests", "4k:50M", "-dry-run", "-p", "/tmp"}, {"-drop-caches"}, {"-json", "-dd-tests", "4k:50M"}, {"-json", "-drop-caches"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted invalid dd tests", args)
+		}
+	}
+}
+
+func TestParseCLIDataPattern(t *testing.T) {
+	opts, err := parseCLI([]string{"-json", "-pattern", "compressible:40"})
+	if err != nil || opts.dataPattern != (disk.DataPattern{Kind: "compressible", CompressPercent: 40}) {
+		t.Fatalf("unexpected pattern %#v err=%v", opts.dataPattern, err)
+	}
+	for _, args := range [][]string{{"-pattern", "ones"}, {"-pattern", "compressible:100"}, {"-capacity", "-pattern", "random"}, {"-scan", "-p", "/tmp/x", "-pattern", "random"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted invalid pattern", args)
+		}
+	}
+}
+
+func TestDiscoverTableHidesPseudoFilesystems(t *testing.T) {
+	if _, err := parseDiscoverCLI([]string{"-json", "-a"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseDiscoverCLI([]string{"/data"}); err == nil {
+		t.Fatal("positional argument accepted")
+	}
+	mounts := []disk.MountInfo{
+		{MountPoint: "/proc", Device: "proc", FsType: "proc", Excluded: "excluded_fs_type"},
+		{MountPoint: "/data", Device: "/dev/sdb1", FsType: "xfs", TotalBytes: 1 << 40, Writable: true},
+	}
+	var output bytes.Buffer
+	writeMountTable(&output, mounts, false, disk.UnitFormat{System: disk.UnitsIEC, Precision: 1})
+	if strings.Contains(output.String(), "/proc") || !strings.Contains(output.String(), "1.0 TiB") || !strings.Contains(output.String(), "test") || !strings.Contains(output.String(), "Units: IEC") {
+		t.Fatalf("unexpected mount table:\n%s", output.String())
+	}
+}
+
+func TestParseCLIDiscoveryFilter(t *testing.T) {
+	opts, err := parseCLI([]string{"-d", "multi", "-exclude-fs", "nfs, nfs4", "-include-mount", "/var/lib/docker/volumes/*", "-min-size", "10G"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := opts.discoveryFilter
+	if len(filter.ExcludeFsTypes) != 2 || filter.IncludeMounts[0] != "/var/lib/docker/volumes/*" || filter.MinSizeBytes != 10<<30 {
+		t.Fatalf("unexpected filter %+v", filter)
+	}
+	for _, args := range [][]string{{"-exclude-device", "("}, {"-min-size", "big"}, {"-json", "-exclude-fs", "nfs"}, {"-capacity", "-min-size", "1G"},
+		{"-exclude-fs", "nfs"}, {"-d", "single", "-one-per-disk"}, {"-p", "/data", "-min-size", "1G"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted an invalid filter", args)
+		}
+	}
+	discoverOpts, err := parseDiscoverCLI([]string{"-exclude-mount", "/srv"})
+	if err != nil || discoverOpts.filter.ExcludeMounts[0] != "/srv" {
+		t.Fatalf("unexpected discover filter %+v err=%v", discoverOpts.filter, err)
+	}
+}
+
+func TestParseCLIMembers(t *testing.T) {
+	opts, err := parseCLI([]string{"-json", "-members", "-allow-device", "-p", "/data"})
+	if err != nil || !opts.members || !opts.allowDevice {
+		t.Fatalf("unexpected options %+v err=%v", opts, err)
+	}
+	for _, args := range [][]string{{"-json", "-members"}, {"-members", "-allow-device"}, {"-json", "-allow-device"}, {"-scan", "-p", "/dev/sda", "-allow-device", "-members"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted -members", args)
+		}
+	}
+}
+
+func TestParseCLIScoreTable(t *testing.T) {
+	dir := t.TempDir()
+	legacy, matrix := filepath.Join(dir, "legacy.json"), filepath.Join(dir, "matrix.json")
+	os.WriteFile(legacy, []byte(`{"version":"site/1","components":[{"id":"iops_4k_mixed","weight":1,"reference":10000,"floor":10}],"classes":[{"id":"fast","label":"Fast","min_mixed_iops":5000},{"id":"any","label":"Any"}]}`), 0o644)
+	os.WriteFile(matrix, []byte(`{"version":"site/1","components":[{"id":"iops_4k_qd32","weight":1,"reference":10000,"floor":10}],"classes":[{"id":"any","label":"Any"}]}`), 0o644)
+	opts, err := parseCLI([]string{"-score-table", legacy})
+	if err != nil || opts.scoreTable == nil || opts.scoreTable.Version != "site/1" {
+		t.Fatalf("unexpected score table %+v err=%v", opts.scoreTable, err)
+	}
+	if opts, err := parseCLI([]string{"-json", "-score-table", matrix}); err != nil || opts.scoreTable == nil {
+		t.Fatalf("matrix score table rejected: %v", err)
+	}
+	for _, args := range [][]string{
+		{"-score-table", legacy, "-m", "dd"},
+		{"-score-table", legacy, "-capacity"},
+		{"-score-table", filepath.Join(dir, "missing.json")},
+		{"-score-table", matrix},
+		{"-json", "-score-table", legacy},
+	} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted the score table", args)
+		}
+	}
+}
+
+func TestParseCLITableFormatAcceptsLanguage(t *testing.T) {
+	opts, err := parseCLI([]string{"-json", "-format", "table", "-l", "en"})
+	if err != nil || opts.format != "table" || opts.language != "en" {
+		t.Fatalf("unexpected options %+v err=%v", opts, err)
+	}
+	if _, err := parseCLI([]string{"-json", "-format", "csv", "-l", "en"}); err == nil {
+		t.Fatal("-l accepted with a machine-readable structured format")
+	}
+}
+
+func TestParseCLIUnits(t *testing.T) {
+	opts, err := parseCLI([]string{"-units", "IEC", "-precision", "0"})
+	if err != nil || opts.units != (disk.UnitFormat{System: disk.UnitsIEC, Precision: 0}) {
+		t.Fatalf("unexpected units %+v err=%v", opts.units, err)
+	}
+	if opts, err := parseCLI([]string{"-json", "-format", "table", "-precision", "1"}); err != nil || opts.units.System != disk.UnitsSI {
+		t.Fatalf("table units %+v err=%v", opts.units, err)
+	}
+	for _, args := range [][]string{{"-units", "jedec"}, {"-precision", "9"}, {"-json", "-units", "iec"}, {"-capacity", "-units", "iec"}, {"-scan", "-p", "/dev/sda", "-units", "iec"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted the units", args)
+		}
+	}
+}
+
+func TestParseCLIDryRun(t *testing.T) {
+	for _, args := range [][]string{{"-dry-run"}, {"-dry-run", "-m", "dd", "-d", "multi"}, {"-json", "-dry-run"}} {
+		if opts, err := parseCLI(args); err != nil || !opts.dryRun {
+			t.Fatalf("parseCLI(%q) = %+v, %v", args, opts, err)
+		}
+	}
+	for _, args := range [][]string{{"-dry-run", "-capacity"}, {"-dry-run", "-format", "csv"}, {"-dry-run", "-textfile", "out.prom"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted -dry-run", args)
+		}
+	}
+}
+
+func TestParseCLIFallback(t *testing.T) {
+	opts, err := parseCLI([]string{"-m", "dd", "-fallback", "fio"})
+	if err != nil || !reflect.DeepEqual(opts.fallbackList, []string{"fio"}) {
+		t.Fatalf("unexpected options %+v, %v", opts, err)
+	}
+	if opts, err := parseCLI([]string{"-fallback", "none"}); err != nil || opts.fallbackList == nil || len(opts.fallbackList) != 0 {
+		t.Fatalf("unexpected options %+v, %v", opts, err)
+	}
+	for _, args := range [][]string{{"-fallback", "sysbench"}, {"-fallback", "dd,"}, {"-json", "-fallback", "dd"}, {"-capacity", "-fallback", "dd"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted -fallback", args)
+		}
+	}
+}
+
+func TestParseCLIFioPath(t *testing.T) {
+	for _, args := range [][]string{{"-fio-path", "/opt/fio/bin/fio"}, {"-json", "-fio-path", "/opt/fio/bin/fio"}} {
+		if opts, err := parseCLI(args); err != nil || opts.fioPath != "/opt/fio/bin/fio" {
+			t.Fatalf("parseCLI(%q) = %+v, %v", args, opts, err)
+		}
+	}
+	for _, args := range [][]string{{"-fio-path", " "}, {"-capacity", "-fio-path", "fio"}, {"-scan", "-p", "/dev/sda", "-fio-path", "fio"}} {
+		if _, err := parseCLI(args); err == nil {
+			t.Fatalf("parseCLI(%q) accepted -fio-path", args)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/oneclickvirt/disktest/disk"
+)
+
+type discoverOptions struct {
+	jsonOutput bool
+	all        bool
+	discovery  discoveryFlags
+	filter     disk.DiscoveryFilter
+	unitFlags  unitFlags
+	units      disk.UnitFormat
+}
+
+func newDiscoverFlagSet(opts *discoverOptions, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("disktest discover", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.BoolVar(&opts.jsonOutput, "json", false, "Print the mounts as JSON")
+	fs.BoolVar(&opts.all, "a", false, "Also list pseudo filesystems such as proc and tmpfs in the table")
+	addDiscoveryFlags(fs, &opts.discovery)
+	addUnitFlags(fs, &opts.unitFlags)
+	return fs
+}
+
+func parseDiscoverCLI(args []string) (discoverOptions, error) {
+	opts := discoverOptions{}
+	fs := newDiscoverFlagSet(&opts, io.Discard)
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() != 0 {
+		return opts, fmt.Errorf("unexpected positional arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	units, err := opts.unitFlags.format()
+	if err != nil {
+		return opts, err
+	}
+	opts.units = units
+	filter, err := opts.discovery.filter()
+	opts.filter = filter
+	return opts, err
+}
+
+// runDiscover prints every mount seen by test-path discovery and why it is
+// or is not tested. It returns the process exit code.
+func runDiscover(opts discoverOptions, output io.Writer) int {
+	mounts, err := disk.DiscoverMountsWithFilter(opts.filter)
+	if err != nil {
+		fmt.Fprintln(output, sanitizeErrorText(err.Error()))
+		return 1
+	}
+	if opts.jsonOutput {
+		encoded, err := json.Marshal(mounts)
+		if err != nil {
+			fmt.Fprintln(output, err)
+			return 1
+		}
+		fmt.Fprintln(output, string(encoded))
+		return 0
+	}
+	writeMountTable(output, mounts, opts.all, opts.units)
+	return 0
+}
+
+func writeMountTable(output io.Writer, mounts []disk.MountInfo, all bool, units disk.UnitFormat) {
+	table := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "MOUNT\tDEVICE\tFS\tSIZE\tFREE\tUSED\tFREE INODES\tMODE\tSTACK\tSTATUS")
+	for _, mount := range mounts {
+		if !all && mount.Excluded == "excluded_fs_type" {
+			continue
+		}
+		mode := "rw"
+		if mount.ReadOnly {
+			mode = "ro"
+		}
+		status := "test"
+		if mount.Excluded != "" {
+			status = mount.Excluded
+		}
+		if mount.AliasOf != "" {
+			status += " (" + mount.AliasOf + ")"
+		}
+		stack := "-"
+		if mount.Stack != nil {
+			stack = mount.Stack.String()
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", mount.MountPoint, mount.Device, mount.FsType,
+			units.Bytes(float64(mount.TotalBytes)), units.Bytes(float64(mount.FreeBytes)), units.Bytes(float64(mount.UsedBytes)),
+			mount.FreeInodes, mode, stack, status)
+	}
+	table.Flush()
+	fmt.Fprintf(output, "Units: %s\n", units)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/oneclickvirt/disktest/disk"
+)
+
+// discoveryFlags holds the mount filter options shared by multi-disk runs,
+// discover and clean.
+type discoveryFlags struct {
+	config                       string
+	includeFs, excludeFs         string
+	includeMount, excludeMount   string
+	includeDevice, excludeDevice string
+	minSize                      string
+	onePerDisk                   bool
+}
+
+var discoveryFlagNames = []string{
+	"filter-config", "include-fs", "exclude-fs", "include-mount", "exclude-mount",
+	"include-device", "exclude-device", "min-size", "one-per-disk",
+}
+
+func addDiscoveryFlags(fs *flag.FlagSet, flags *discoveryFlags) {
+	fs.StringVar(&flags.config, "filter-config", "", "JSON file with mount discovery rules")
+	fs.StringVar(&flags.includeFs, "include-fs", "", "Comma-separated filesystem types to test even if excluded by default")
+	fs.StringVar(&flags.excludeFs, "exclude-fs", "", "Comma-separated filesystem types to skip (for example nfs,nfs4)")
+	fs.StringVar(&flags.includeMount, "include-mount", "", "Comma-separated mount globs to test even if excluded by default")
+	fs.StringVar(&flags.excludeMount, "exclude-mount", "", "Comma-separated mount globs to skip")
+	fs.StringVar(&flags.includeDevice, "include-device", "", "Regular expression of devices to test even if excluded by default")
+	fs.StringVar(&flags.excludeDevice, "exclude-device", "", "Regular expression of devices to skip")
+	fs.StringVar(&flags.minSize, "min-size", "", "Skip mounts smaller than this size (for example 10G)")
+	fs.BoolVar(&flags.onePerDisk, "one-per-disk", false, "Test one mount per physical disk")
+}
+
+// filter loads -filter-config and adds the rules given as flags.
+func (flags discoveryFlags) filter() (disk.DiscoveryFilter, error) {
+	var filter disk.DiscoveryFilter
+	if flags.config != "" {
+		loaded, err := disk.LoadDiscoveryFilter(flags.config)
+		if err != nil {
+			return filter, err
+		}
+		filter = loaded
+	}
+	extra := disk.DiscoveryFilter{
+		IncludeFsTypes: splitList(flags.includeFs),
+		ExcludeFsTypes: splitList(flags.excludeFs),
+		IncludeMounts:  splitList(flags.includeMount),
+		ExcludeMounts:  splitList(flags.excludeMount),
+		IncludeDevice:  strings.TrimSpace(flags.includeDevice),
+		ExcludeDevice:  strings.TrimSpace(flags.excludeDevice),
+		OnePerDisk:     flags.onePerDisk,
+	}
+	if flags.minSize != "" {
+		minSize, err := disk.ParseSize(flags.minSize)
+		if err != nil {
+			return filter, err
+		}
+		extra.MinSizeBytes = minSize
+	}
+	filter = filter.Merge(extra)
+	return filter, filter.Validate()
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -8,8 +8,11 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
 	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/oneclickvirt/disktest/disk"
@@ -17,14 +20,38 @@ import (
 
 type cliOptions struct {
 	help, version, jsonOutput, deep, log  bool
+	capacity, scan, allowDevice, members  bool
+	dropCaches, dryRun                    bool
 	language, testMethod, multiDisk, path string
-	sizeBytes                             int64
+	format, textfile, fioBlockSizes       string
+	ddTestList, pattern, scoreTablePath   string
+	fallback, fioPath                     string
+	unitFlags                             unitFlags
+	units                                 disk.UnitFormat
+	scoreTable                            *disk.ScoreTable
+	dataPattern                           disk.DataPattern
+	fioBlockSizeList                      []string
+	ddTests                               []disk.DDTestSpec
+	fallbackList                          []string
+	discovery                             discoveryFlags
+	discoveryFilter                       disk.DiscoveryFilter
+	fioRuntime                            time.Duration
+	fioIODepth, fioNumJobs                int
+	sizeBytes, chunkBytes                 int64
+	fraction                              float64
 	timeout, runtime                      time.Duration
 	languageSet, methodSet, multiDiskSet  bool
 	pathSet, sizeSet, timeoutSet          bool
-	runtimeSet                            bool
+	runtimeSet, fractionSet, chunkSet     bool
+	fioSet, ddTestsSet, patternSet        bool
+	filterSet, unitsSet, fallbackSet      bool
+	fioPathSet, fioBlockSizesSet          bool
+	fioRuntimeSet, fioIODepthSet          bool
+	fioNumJobsSet                         bool
 }
 
+var fioBlockSizePattern = regexp.MustCompile(`^[1-9][0-9]*[kmg]?$`)
+
 func parseCLI(args []string) (cliOptions, error) {
 	opts := cliOptions{}
 	fs := newFlagSet(&opts, io.Discard)
@@ -40,6 +67,10 @@ func parseCLI(args []string) (cliOptions, error) {
 			opts.languageSet = true
 		case "m":
 			opts.methodSet = true
+		case "fallback":
+			opts.fallbackSet = true
+		case "fio-path":
+			opts.fioPathSet = true
 		case "d":
 			opts.multiDiskSet = true
 		case "p":
@@ -50,19 +81,57 @@ func parseCLI(args []string) (cliOptions, error) {
 			opts.timeoutSet = true
 		case "size":
 			opts.sizeSet = true
+		case "fraction":
+			opts.fractionSet = true
+		case "chunk":
+			opts.chunkSet = true
+		case "fio-bs":
+			opts.fioSet, opts.fioBlockSizesSet = true, true
+		case "fio-runtime":
+			opts.fioSet, opts.fioRuntimeSet = true, true
+		case "fio-iodepth":
+			opts.fioSet, opts.fioIODepthSet = true, true
+		case "fio-numjobs":
+			opts.fioSet, opts.fioNumJobsSet = true, true
+		case "dd-tests", "drop-caches":
+			opts.ddTestsSet = true
+		case "pattern":
+			opts.patternSet = true
+		case "units", "precision":
+			opts.unitsSet = true
+		default:
+			if containsString(discoveryFlagNames, current.Name) {
+				opts.filterSet = true
+			}
 		}
 	})
 	opts.language = strings.ToLower(strings.TrimSpace(opts.language))
 	opts.testMethod = strings.ToLower(strings.TrimSpace(opts.testMethod))
 	opts.multiDisk = strings.ToLower(strings.TrimSpace(opts.multiDisk))
 	opts.path = strings.TrimSpace(opts.path)
+	opts.format = strings.ToLower(strings.TrimSpace(opts.format))
+	opts.textfile = strings.TrimSpace(opts.textfile)
 	if opts.help || opts.version {
 		return opts, nil
 	}
+	if opts.textfile != "" {
+		if opts.format == "" {
+			opts.format = "prometheus"
+		}
+		if opts.format == "json" {
+			return opts, fmt.Errorf("-textfile does not support the json format")
+		}
+	}
+	if opts.format != "" && opts.format != "json" && !containsString(disk.ReportFormats(), opts.format) {
+		return opts, fmt.Errorf("output format must be json or one of %s", strings.Join(disk.ReportFormats(), ", "))
+	}
+	if opts.format != "" && (opts.capacity || opts.scan) {
+		return opts, fmt.Errorf("-format and -textfile are not used with -capacity or -scan")
+	}
 	if opts.language != "" && opts.language != "en" && opts.language != "zh" {
 		return opts, fmt.Errorf("language must be en or zh")
 	}
-	if opts.testMethod != "" && opts.testMethod != "fio" && opts.testMethod != "dd" && !(runtime.GOOS == "windows" && opts.testMethod == "winsat") {
+	if opts.testMethod != "" && !isCLIMethod(opts.testMethod) {
 		return opts, fmt.Errorf("disk method must be fio or dd")
 	}
 	if opts.multiDisk != "" && opts.multiDisk != "single" && opts.multiDisk != "multi" {
@@ -71,12 +140,71 @@ func parseCLI(args []string) (cliOptions, error) {
 	if opts.pathSet && opts.path == "" {
 		return opts, fmt.Errorf("disk path must not be empty when specified")
 	}
+	if opts.capacity && opts.scan {
+		return opts, fmt.Errorf("-capacity and -scan cannot be combined")
+	}
+	if opts.dryRun && (opts.capacity || opts.scan || opts.format != "") {
+		return opts, fmt.Errorf("-dry-run is not used with -capacity, -scan, -format, or -textfile")
+	}
+	if opts.scan {
+		if opts.jsonOutput || opts.deep || opts.languageSet || opts.methodSet || opts.multiDiskSet || opts.runtimeSet || opts.sizeSet || opts.fractionSet || opts.fioSet || opts.ddTestsSet || opts.patternSet || opts.filterSet || opts.members || opts.scoreTablePath != "" || opts.unitsSet || opts.fallbackSet || opts.fioPathSet {
+			return opts, fmt.Errorf("-scan only accepts -p, -chunk, -allow-device, and -timeout")
+		}
+		if !opts.pathSet {
+			return opts, fmt.Errorf("-scan requires -p with a file, image, or block device")
+		}
+		if opts.chunkSet && opts.chunkBytes < 4096 {
+			return opts, fmt.Errorf("scan chunk size must be at least 4096 bytes")
+		}
+		if opts.timeoutSet && opts.timeout <= 0 {
+			return opts, fmt.Errorf("scan timeout must be greater than zero")
+		}
+		return opts, nil
+	}
+	if opts.patternSet {
+		if opts.capacity {
+			return opts, fmt.Errorf("-pattern is not used with -capacity")
+		}
+		pattern, err := disk.ParseDataPattern(opts.pattern)
+		if err != nil {
+			return opts, err
+		}
+		opts.dataPattern = pattern
+	}
+	if opts.unitsSet {
+		units, err := opts.unitFlags.format()
+		if err != nil {
+			return opts, err
+		}
+		opts.units = units
+	}
+	if opts.chunkSet || (opts.allowDevice && !opts.members) {
+		return opts, fmt.Errorf("-chunk and -allow-device require -scan")
+	}
+	if opts.members && (!opts.allowDevice || opts.capacity || !(opts.jsonOutput || opts.deep)) {
+		return opts, fmt.Errorf("-members requires structured output and -allow-device")
+	}
+	if opts.capacity {
+		if opts.jsonOutput || opts.deep || opts.languageSet || opts.methodSet || opts.multiDiskSet || opts.runtimeSet || opts.sizeSet || opts.fioSet || opts.ddTestsSet || opts.filterSet || opts.scoreTablePath != "" || opts.unitsSet || opts.fallbackSet || opts.fioPathSet {
+			return opts, fmt.Errorf("-capacity only accepts -p, -fraction, and -timeout")
+		}
+		if opts.fractionSet && (opts.fraction <= 0 || opts.fraction > 1) {
+			return opts, fmt.Errorf("capacity fraction must be greater than zero and at most 1")
+		}
+		if opts.timeoutSet && opts.timeout <= 0 {
+			return opts, fmt.Errorf("capacity timeout must be greater than zero")
+		}
+		return opts, nil
+	}
+	if opts.fractionSet {
+		return opts, fmt.Errorf("-fraction requires -capacity")
+	}
 	if opts.deep {
 		opts.jsonOutput = true
 	}
 	if opts.jsonOutput {
-		if opts.languageSet || opts.methodSet || opts.multiDiskSet {
-			return opts, fmt.Errorf("-l, -m, and -d are not used with structured output")
+		if ((opts.languageSet || opts.unitsSet) && opts.format != "table") || opts.methodSet || opts.multiDiskSet || opts.fioSet || opts.ddTestsSet || opts.filterSet || opts.fallbackSet {
+			return opts, fmt.Errorf("-l and -units (except with -format table), -m, -d, -fallback, -fio-*, -dd-tests, -drop-caches, and mount filters are not used with structured output")
 		}
 		if opts.runtimeSet && (opts.runtime <= 0 || opts.runtime > 10*time.Second) {
 			return opts, fmt.Errorf("structured duration must be greater than zero and at most 10s")
@@ -93,10 +221,112 @@ func parseCLI(args []string) (cliOptions, error) {
 		}
 	} else if opts.runtimeSet || opts.timeoutSet || opts.sizeSet {
 		return opts, fmt.Errorf("-duration, -timeout, and -size require structured output")
+	} else if opts.format == "json" {
+		return opts, fmt.Errorf("-format json requires structured output")
+	} else if opts.format == "prometheus" || opts.format == "openmetrics" {
+		// 传统测试没有按场景的FIO指标，指标格式只会输出disktest_up
+		return opts, fmt.Errorf("-format %s and the -textfile default require structured output", opts.format)
+	}
+	if opts.fioPathSet && strings.TrimSpace(opts.fioPath) == "" {
+		return opts, fmt.Errorf("fio path must not be empty when specified")
+	}
+	if opts.fallbackSet {
+		opts.fallbackList = []string{}
+		if value := strings.ToLower(strings.TrimSpace(opts.fallback)); value != "none" {
+			for _, method := range strings.Split(value, ",") {
+				if method = strings.TrimSpace(method); !isCLIMethod(method) {
+					return opts, fmt.Errorf("invalid fallback method %q", method)
+				}
+				opts.fallbackList = append(opts.fallbackList, method)
+			}
+		}
+	}
+	if opts.fioSet {
+		if opts.testMethod != "" && opts.testMethod != "fio" {
+			return opts, fmt.Errorf("-fio-* options require the fio method")
+		}
+		for _, blockSize := range strings.Split(strings.ToLower(opts.fioBlockSizes), ",") {
+			if blockSize = strings.TrimSpace(blockSize); blockSize != "" {
+				if !fioBlockSizePattern.MatchString(blockSize) {
+					return opts, fmt.Errorf("invalid fio block size %q", blockSize)
+				}
+				opts.fioBlockSizeList = append(opts.fioBlockSizeList, blockSize)
+			}
+		}
+		// 显式给出的0或空值会被静默替换为默认值，直接拒绝
+		if opts.fioBlockSizesSet && len(opts.fioBlockSizeList) == 0 {
+			return opts, fmt.Errorf("fio block sizes must not be empty when specified")
+		}
+		if opts.fioRuntimeSet && (opts.fioRuntime < time.Second || opts.fioRuntime > 10*time.Minute) {
+			return opts, fmt.Errorf("fio runtime must be between 1s and 10m")
+		}
+		if opts.fioIODepthSet && (opts.fioIODepth < 1 || opts.fioIODepth > 1024) {
+			return opts, fmt.Errorf("fio iodepth must be between 1 and 1024")
+		}
+		if opts.fioNumJobsSet && (opts.fioNumJobs < 1 || opts.fioNumJobs > 64) {
+			return opts, fmt.Errorf("fio numjobs must be between 1 and 64")
+		}
+	}
+	if opts.ddTestsSet && opts.testMethod != "dd" {
+		return opts, fmt.Errorf("-dd-tests and -drop-caches require the dd method")
+	}
+	if opts.ddTestList != "" {
+		ddTests, err := disk.ParseDDTests(opts.ddTestList)
+		if err != nil {
+			return opts, err
+		}
+		opts.ddTests = ddTests
+	}
+	if opts.scoreTablePath != "" {
+		if opts.testMethod != "" && opts.testMethod != "fio" {
+			return opts, fmt.Errorf("-score-table requires the fio method")
+		}
+		table, err := disk.LoadScoreTable(opts.scoreTablePath)
+		if err != nil {
+			return opts, err
+		}
+		// 结构化矩阵与传统表格测得的输入不同，评分表必须与运行模式匹配
+		if opts.jsonOutput {
+			err = table.ValidateMatrix()
+		} else {
+			err = table.ValidateLegacy()
+		}
+		if err != nil {
+			return opts, err
+		}
+		opts.scoreTable = &table
+	}
+	if opts.filterSet {
+		if opts.multiDisk != "multi" {
+			return opts, fmt.Errorf("mount filters require -d multi")
+		}
+		filter, err := opts.discovery.filter()
+		if err != nil {
+			return opts, err
+		}
+		opts.discoveryFilter = filter
 	}
 	return opts, nil
 }
 
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// isCLIMethod winsat仅在Windows上可选，其余为已注册的测试方式
+func isCLIMethod(method string) bool {
+	if method == "winsat" && runtime.GOOS != "windows" {
+		return false
+	}
+	_, exists := disk.LookupBackend(method)
+	return exists
+}
+
 func newFlagSet(opts *cliOptions, output io.Writer) *flag.FlagSet {
 	fs := flag.NewFlagSet("disktest", flag.ContinueOnError)
 	fs.SetOutput(output)
@@ -104,6 +334,7 @@ func newFlagSet(opts *cliOptions, output io.Writer) *flag.FlagSet {
 	fs.BoolVar(&opts.version, "v", false, "Show version")
 	fs.StringVar(&opts.language, "l", "", "Language parameter (en or zh)")
 	fs.StringVar(&opts.testMethod, "m", "", "Specific Test Method (dd or fio)")
+	fs.StringVar(&opts.fallback, "fallback", "", "Comma-separated methods tried when -m is unavailable, or none (default dd for fio, fio for dd)")
 	fs.StringVar(&opts.multiDisk, "d", "", "Enable multi disk check parameter (single or multi, default is single)")
 	fs.StringVar(&opts.path, "p", "", "Specific Test Disk Path (default is /root or C:)")
 	fs.BoolVar(&opts.log, "log", false, "Enable logging")
@@ -113,12 +344,38 @@ func newFlagSet(opts *cliOptions, output io.Writer) *flag.FlagSet {
 	fs.DurationVar(&opts.runtime, "duration", 0, "Per-scenario FIO runtime (for example 5s)")
 	fs.DurationVar(&opts.timeout, "timeout", 0, "FIO matrix timeout (for example 60s)")
 	fs.Int64Var(&opts.sizeBytes, "size", 0, "Temporary test-file size in bytes")
+	fs.StringVar(&opts.format, "format", "", "Output format: json (structured only), table, csv, markdown, html, junit, prometheus, or openmetrics")
+	fs.StringVar(&opts.textfile, "textfile", "", "Atomically write the report to this file (default format prometheus, structured only)")
+	fs.BoolVar(&opts.capacity, "capacity", false, "Verify the real usable capacity of the test path and print JSON")
+	fs.Float64Var(&opts.fraction, "fraction", 0, "Fraction of free space filled by -capacity (default 0.1)")
+	fs.BoolVar(&opts.scan, "scan", false, "Read the -p file, image, or block device and print a latency map as JSON")
+	fs.Int64Var(&opts.chunkBytes, "chunk", 0, "Read chunk size in bytes for -scan (default 1048576)")
+	fs.BoolVar(&opts.allowDevice, "allow-device", false, "Allow -scan or -members to read raw block devices")
+	fs.BoolVar(&opts.members, "members", false, "After the structured matrix, scan each physical disk under -p read-only")
+	fs.StringVar(&opts.fioPath, "fio-path", "", "fio binary used instead of the system or embedded one (default $"+disk.FioBinaryEnv+")")
+	fs.StringVar(&opts.fioBlockSizes, "fio-bs", "", "Comma-separated block sizes for the fio table (default 4k,64k,512k,1m)")
+	fs.DurationVar(&opts.fioRuntime, "fio-runtime", 0, "Runtime of each fio table row (default 30s)")
+	fs.IntVar(&opts.fioIODepth, "fio-iodepth", 0, "Queue depth of the fio table (default 64)")
+	fs.IntVar(&opts.fioNumJobs, "fio-numjobs", 0, "Job count of the fio table (default 2)")
+	fs.StringVar(&opts.pattern, "pattern", "", "Written data: zeros, random, or compressible:N (default zeros for dd, fio's buffers for fio)")
+	fs.BoolVar(&opts.dropCaches, "drop-caches", false, "As root, flush the page cache before dd reads that cannot use iflag=direct")
+	fs.StringVar(&opts.ddTestList, "dd-tests", "", "Comma-separated dd block:total pairs, total a multiple of block (default 4k:100M,1M:1000M)")
+	fs.StringVar(&opts.scoreTablePath, "score-table", "", "JSON file replacing the built-in disk score reference table")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "Print the paths, sizes, commands, time budget and peak disk space without running the test")
+	addUnitFlags(fs, &opts.unitFlags)
+	addDiscoveryFlags(fs, &opts.discovery)
 	return fs
 }
 
 func printCLIHelp(program string) {
 	fmt.Printf("Usage: %s [options]\n", program)
 	newFlagSet(&cliOptions{}, os.Stdout).PrintDefaults()
+	fmt.Printf("\nUsage: %s clean [-p path]... [-y] [mount filters]\n", program)
+	fmt.Println("  Remove temporary files left behind by interrupted runs")
+	newCleanFlagSet(&cleanOptions{}, os.Stdout).PrintDefaults()
+	fmt.Printf("\nUsage: %s discover [-a] [-json] [mount filters]\n", program)
+	fmt.Println("  List mounts with capacity details and whether they are tested")
+	newDiscoverFlagSet(&discoverOptions{}, os.Stdout).PrintDefaults()
 }
 
 func selectCLIAction(opts cliOptions) string {
@@ -128,6 +385,12 @@ func selectCLIAction(opts cliOptions) string {
 	if opts.version {
 		return "version"
 	}
+	if opts.capacity {
+		return "capacity"
+	}
+	if opts.scan {
+		return "scan"
+	}
 	if opts.jsonOutput {
 		return "structured"
 	}
@@ -135,12 +398,30 @@ func selectCLIAction(opts cliOptions) string {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "clean" {
+		cleanOpts, err := parseCleanCLI(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, sanitizeErrorText(err.Error()))
+			os.Exit(2)
+		}
+		os.Exit(runClean(cleanOpts, os.Stdin, os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "discover" {
+		discoverOpts, err := parseDiscoverCLI(os.Args[2:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, sanitizeErrorText(err.Error()))
+			os.Exit(2)
+		}
+		os.Exit(runDiscover(discoverOpts, os.Stdout))
+	}
 	opts, err := parseCLI(os.Args[1:])
 	if err != nil {
 		fmt.Fprintln(os.Stderr, sanitizeErrorText(err.Error()))
 		os.Exit(2)
 	}
 	disk.EnableLoger = opts.log
+	ctx, stop := signalContext()
+	defer stop()
 	action := selectCLIAction(opts)
 	if action == "help" || action == "version" {
 		printLegacyHeader()
@@ -151,27 +432,37 @@ func main() {
 		fmt.Println(disk.DiskTestVersion)
 		return
 	}
+	if action == "capacity" {
+		config := disk.CapacityConfig{Path: opts.path, Fraction: opts.fraction, MaxDuration: opts.timeout}
+		result := disk.RunCapacityCheck(ctx, config)
+		printJSONResult(result, result.Status)
+		return
+	}
+	if action == "scan" {
+		config := disk.ScanConfig{Path: opts.path, ChunkBytes: opts.chunkBytes, AllowDevice: opts.allowDevice, MaxDuration: opts.timeout}
+		result := disk.RunSurfaceScan(ctx, config)
+		printJSONResult(result, result.Status)
+		return
+	}
 	if action == "structured" {
-		config := disk.MatrixConfig{Path: opts.path, SizeBytes: opts.sizeBytes, Runtime: opts.runtime, MaxDuration: opts.timeout}
-		ctx := context.Background()
+		config := disk.MatrixConfig{Path: opts.path, SizeBytes: opts.sizeBytes, Runtime: opts.runtime, MaxDuration: opts.timeout, DataPattern: opts.dataPattern,
+			ScanMembers: opts.members, AllowDevice: opts.allowDevice, ScoreTable: opts.scoreTable, DryRun: opts.dryRun, FioPath: opts.fioPath, Units: opts.units}
 		result := disk.MatrixResult{}
 		if opts.deep {
 			result = disk.RunDeepFioMatrix(ctx, config)
 		} else {
 			result = disk.RunStandardFioMatrix(ctx, config)
 		}
-		encoded, marshalErr := json.Marshal(result)
-		if marshalErr != nil {
-			fmt.Fprintln(os.Stderr, marshalErr)
-			return
-		}
-		fmt.Println(string(encoded))
-		if result.Status != "ok" {
-			os.Exit(1)
+		if opts.format != "" && opts.format != "json" {
+			printReport(opts, disk.MatrixReport(result))
+		} else {
+			printJSONResult(result, result.Status)
 		}
 		return
 	}
-	printLegacyHeader()
+	if opts.format == "" {
+		printLegacyHeader()
+	}
 	language, testMethod, testPath, multiDisk := opts.language, opts.testMethod, opts.path, opts.multiDisk
 	var res string
 	var isMultiCheck bool
@@ -185,62 +476,58 @@ func main() {
 	} else if multiDisk == "multi" {
 		isMultiCheck = true
 	}
-	if testMethod == "" || testMethod == "fio" {
+	if testMethod == "" {
 		testMethod = "fio"
-	} else if testMethod == "dd" {
-		testMethod = "dd"
+	}
+	fallback := disk.DefaultFallback(testMethod)
+	if opts.fallbackSet {
+		fallback = opts.fallbackList
 	}
 	if testPath == "" {
 		testPath = ""
 	} else if testPath != "" {
 		testPath = strings.TrimSpace(testPath)
 	}
-	switch testMethod {
-	case "fio":
-		res = disk.FioTest(language, isMultiCheck, testPath)
-		if res == "" {
-			fallback := disk.DDTest(language, isMultiCheck, testPath)
-			if fallback != "" {
-				if language == "en" {
-					res = "Fio test unavailable, switched to DD.\n"
-				} else {
-					res = "Fio测试不可用，已切换至DD测试。\n"
-				}
-				res += fallback
-			} else if language == "en" {
-				res = "Disk benchmark unavailable.\n"
-			} else {
-				res = "磁盘性能测试不可用。\n"
-			}
-		}
-	case "dd":
-		res = disk.DDTest(language, isMultiCheck, testPath)
-		if res == "" {
-			fallback := disk.FioTest(language, isMultiCheck, testPath)
-			if fallback != "" {
-				if language == "en" {
-					res = "DD test unavailable, switched to Fio.\n"
-				} else {
-					res = "DD测试不可用，已切换至Fio测试。\n"
-				}
-				res += fallback
-			} else if language == "en" {
-				res = "Disk benchmark unavailable.\n"
-			} else {
-				res = "磁盘性能测试不可用。\n"
-			}
+	testOptions := disk.TestOptions{Context: ctx, Language: language, MultiCheck: isMultiCheck, Paths: []string{testPath},
+		FioRuntime: opts.fioRuntime, FioBlockSizes: opts.fioBlockSizeList, FioIODepth: opts.fioIODepth, FioNumJobs: opts.fioNumJobs, DDTests: opts.ddTests, DDDropCaches: opts.dropCaches, DataPattern: opts.dataPattern, DiscoveryFilter: opts.discoveryFilter, ScoreTable: opts.scoreTable, Units: opts.units, DryRun: opts.dryRun, FioPath: opts.fioPath}
+	testOptions.Method, testOptions.Fallback = testMethod, fallback
+	result := disk.RunTest(testOptions)
+	if result.Method == "winsat" {
+		res = "Detected host is Windows, using Winsat for testing.\n"
+	}
+	if len(result.Unavailable) > 0 && result.Status != "unavailable" {
+		res += switchedMessage(language, result.Unavailable[0], result.Method)
+	}
+	res += result.Text
+	if result.Status == "unavailable" && result.Text == "" && ctx.Err() == nil {
+		if language == "en" {
+			res = "Disk benchmark unavailable.\n"
+		} else {
+			res = "磁盘性能测试不可用。\n"
 		}
-	default:
-		if runtime.GOOS == "windows" {
-			res = "Detected host is Windows, using Winsat for testing.\n"
-			res += disk.WinsatTest(language, isMultiCheck, testPath)
+	}
+	canceled := ctx.Err() != nil
+	if canceled && res == "" {
+		if language == "en" {
+			res = "Disk benchmark canceled.\n"
 		} else {
-			res = "Unsupported test method specified.\n"
+			res = "磁盘性能测试已取消。\n"
+		}
+	}
+	if opts.format != "" {
+		report := disk.TestResultReport(result)
+		if canceled {
+			report.Status = "canceled"
 		}
+		printReport(opts, report)
+		return
 	}
 	fmt.Println(" --------------------------------------------------")
 	fmt.Print(indentLegacyOutput(res))
 	fmt.Println(" --------------------------------------------------")
+	if canceled {
+		os.Exit(130)
+	}
 	// TODO https://github.com/devlights/diskio
 	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
 		fmt.Println("Press Enter to exit...")
@@ -248,6 +535,70 @@ func main() {
 	}
 }
 
+// methodLabels 切换提示中使用的测试方式名称
+var methodLabels = map[string]string{"fio": "Fio", "dd": "DD", "winsat": "Winsat"}
+
+// switchedMessage 主测试方式不可用、已改用回退方式时的提示
+func switchedMessage(language, from, to string) string {
+	fromLabel, toLabel := methodLabels[from], methodLabels[to]
+	if fromLabel == "" {
+		fromLabel = from
+	}
+	if toLabel == "" {
+		toLabel = to
+	}
+	if language == "en" {
+		return fmt.Sprintf("%s test unavailable, switched to %s.\n", fromLabel, toLabel)
+	}
+	return fmt.Sprintf("%s测试不可用，已切换至%s测试。\n", fromLabel, toLabel)
+}
+
+// signalContext is canceled by the first SIGINT or SIGTERM so running tests
+// stop their fio/dd processes and remove temporary files before exiting. The
+// handler is released afterwards, letting a second signal kill the process.
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
+
+// printJSONResult prints one compact JSON document and exits non-zero unless
+// the reported status is ok, or planned for a dry run.
+func printJSONResult(result interface{}, status string) {
+	encoded, marshalErr := json.Marshal(result)
+	if marshalErr != nil {
+		fmt.Fprintln(os.Stderr, marshalErr)
+		return
+	}
+	fmt.Println(string(encoded))
+	if status != "ok" && status != "planned" {
+		os.Exit(1)
+	}
+}
+
+// printReport renders a result through one of the registered export formats,
+// to stdout or atomically into -textfile, and exits non-zero unless the
+// reported status is ok, or planned for a dry run.
+func printReport(opts cliOptions, report disk.Report) {
+	report.Language, report.Units = opts.language, opts.units
+	var err error
+	if opts.textfile != "" {
+		err = disk.WriteReportFile(opts.textfile, opts.format, report)
+	} else {
+		err = disk.WriteReport(os.Stdout, opts.format, report)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, sanitizeErrorText(err.Error()))
+		os.Exit(1)
+	}
+	if report.Status != "ok" && report.Status != "planned" {
+		os.Exit(1)
+	}
+}
+
 func printLegacyHeader() {
 	go func() {
 		http.Get("https://hits.spiritlhl.net/disktest.svg?action=hit&title=Hits&title_bg=%23555555&count_bg=%230eecf8&edge_flat=false")
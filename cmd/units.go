@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/oneclickvirt/disktest/disk"
+)
+
+// unitFlags holds -units and -precision, shared by benchmark runs, discover
+// and clean.
+type unitFlags struct {
+	system    string
+	precision int
+}
+
+func addUnitFlags(fs *flag.FlagSet, flags *unitFlags) {
+	fs.StringVar(&flags.system, "units", "si", "Byte and bandwidth units: si (kB, MB, GB) or iec (KiB, MiB, GiB)")
+	fs.IntVar(&flags.precision, "precision", 2, "Decimals shown for sizes and bandwidth, 0 through 6")
+}
+
+// format validates the flags as a unit format.
+func (flags unitFlags) format() (disk.UnitFormat, error) {
+	system, err := disk.ParseUnitSystem(flags.system)
+	if err != nil {
+		return disk.UnitFormat{}, err
+	}
+	format := disk.UnitFormat{System: system, Precision: flags.precision}
+	return format, format.Validate()
+}
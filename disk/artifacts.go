@@ -0,0 +1,119 @@
+package disk
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// artifactMarkerSuffix names the empty sidecar written next to every
+// temporary file disktest creates. Cleanup only removes files that carry it,
+// so user files that happen to share a name are never touched.
+const artifactMarkerSuffix = ".disktest"
+
+// artifactNamePattern matches the names of disktest's temporary files.
+var artifactNamePattern = regexp.MustCompile(`^(test\.fio|(read_)?\d+(MB|GB)\.test|zero_temp|pattern_temp|\.temp_write_check|fio_engine_check|\.goecs-(fio|fio-engine|capacity|write-probe)-\d+)$`)
+
+// Artifact is a leftover temporary file from an interrupted disktest run.
+// Marker is empty when only an orphaned marker is left.
+type Artifact struct {
+	Path   string `json:"path"`
+	Marker string `json:"marker,omitempty"`
+	Size   int64  `json:"size_bytes"`
+}
+
+// markArtifact 在临时文件旁写入标记，标记失败时清理命令不会删除该文件
+func markArtifact(path string) {
+	if file, err := os.OpenFile(path+artifactMarkerSuffix, os.O_WRONLY|os.O_CREATE, 0o644); err == nil {
+		file.Close()
+	}
+}
+
+// removeArtifact 删除临时文件及其标记
+func removeArtifact(path string) {
+	os.Remove(path)
+	os.Remove(path + artifactMarkerSuffix)
+}
+
+// FindArtifacts lists disktest temporary files in the given directories. A
+// file is reported only when its name matches disktest's naming and its
+// marker exists; orphaned markers are reported on their own.
+func FindArtifacts(paths []string) []Artifact {
+	var artifacts []Artifact
+	seen := make(map[string]struct{})
+	for _, directory := range paths {
+		if directory = strings.TrimSpace(directory); directory == "" {
+			continue
+		}
+		if _, exists := seen[directory]; exists {
+			continue
+		}
+		seen[directory] = struct{}{}
+		entries, err := os.ReadDir(directory)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := strings.TrimSuffix(entry.Name(), artifactMarkerSuffix)
+			if name == entry.Name() || !entry.Type().IsRegular() || !artifactNamePattern.MatchString(name) {
+				continue
+			}
+			marker := filepath.Join(directory, entry.Name())
+			artifact := Artifact{Path: filepath.Join(directory, name), Marker: marker}
+			if info, err := os.Lstat(artifact.Path); err == nil && info.Mode().IsRegular() {
+				artifact.Size = info.Size()
+			} else if err == nil {
+				continue
+			} else {
+				artifact.Path, artifact.Marker = marker, ""
+			}
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Path < artifacts[j].Path })
+	return artifacts
+}
+
+// RemoveArtifacts deletes artifacts returned by FindArtifacts together with
+// their markers. It keeps going after a failure and returns the number of
+// artifacts removed and the first error.
+func RemoveArtifacts(artifacts []Artifact) (int, error) {
+	removed := 0
+	var firstErr error
+	for _, artifact := range artifacts {
+		err := os.Remove(artifact.Path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if artifact.Marker != "" {
+			os.Remove(artifact.Marker)
+		}
+		removed++
+	}
+	return removed, firstErr
+}
+
+// ArtifactSearchPaths returns the directories disktest writes temporary files
+// to by default: the root and temporary test paths plus every discovered
+// writable mount, followed by extra.
+func ArtifactSearchPaths(extra ...string) []string {
+	return ArtifactSearchPathsWithFilter(DiscoveryFilter{}, extra...)
+}
+
+// ArtifactSearchPathsWithFilter is ArtifactSearchPaths with the mounts chosen
+// by filter, matching a run that used the same DiscoveryFilter.
+func ArtifactSearchPathsWithFilter(filter DiscoveryFilter, extra ...string) []string {
+	rootPath, tmpPath := getDefaultTestPaths()
+	paths := []string{rootPath, tmpPath, os.TempDir()}
+	// 只检查权限而不写探测文件，clean不应在每个挂载点上留下新的写入
+	if pathInfo, err := getTestPathsWith(filter, planWritable); err == nil {
+		paths = append(paths, pathInfo.MountPoints...)
+	}
+	return append(paths, extra...)
+}
@@ -0,0 +1,68 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindArtifactsRequiresNameAndMarker(t *testing.T) {
+	directory := t.TempDir()
+	for name, content := range map[string]string{
+		"test.fio":                    "fio data",
+		"test.fio.disktest":           "",
+		"100MB.test":                  "unmarked user file",
+		"notes.txt.disktest":          "",
+		".goecs-capacity-42.disktest": "",
+	} {
+		if err := os.WriteFile(filepath.Join(directory, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	artifacts := FindArtifacts([]string{directory, directory, filepath.Join(directory, "missing")})
+	if len(artifacts) != 2 {
+		t.Fatalf("unexpected artifacts: %+v", artifacts)
+	}
+	if artifacts[0].Path != filepath.Join(directory, ".goecs-capacity-42.disktest") || artifacts[0].Marker != "" {
+		t.Fatalf("orphaned marker not reported: %+v", artifacts[0])
+	}
+	if artifacts[1].Path != filepath.Join(directory, "test.fio") || artifacts[1].Size != 8 {
+		t.Fatalf("marked test file not reported: %+v", artifacts[1])
+	}
+	removed, err := RemoveArtifacts(artifacts)
+	if err != nil || removed != 2 {
+		t.Fatalf("RemoveArtifacts = %d, %v", removed, err)
+	}
+	entries, _ := os.ReadDir(directory)
+	if len(entries) != 2 {
+		t.Fatalf("cleanup touched unmarked files: %v", entries)
+	}
+}
+
+func TestRemoveArtifactDropsMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "zero_temp")
+	markArtifact(path)
+	if err := os.WriteFile(path, []byte{0}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if artifacts := FindArtifacts([]string{filepath.Dir(path)}); len(artifacts) != 1 {
+		t.Fatalf("marked artifact not found: %+v", artifacts)
+	}
+	removeArtifact(path)
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 0 {
+		t.Fatalf("artifact or marker left behind: %v", entries)
+	}
+}
+
+func TestWritableProbeLeavesNothingBehind(t *testing.T) {
+	directory := t.TempDir()
+	if !isWritableMountpoint(directory) {
+		t.Fatal("temporary directory reported read-only")
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 0 {
+		t.Fatalf("write probe left files behind: %v", entries)
+	}
+	if !planWritable(directory) {
+		t.Fatal("planWritable rejected a writable directory")
+	}
+}
@@ -0,0 +1,205 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend is one benchmark engine RunTest can select by name. Probe checks
+// cheaply, without extracting or executing anything, that the engine can run
+// on this host with opts before Run is called; Run returns the typed result
+// together with its rendered table. A Status of unavailable lets RunTest
+// move on to the next fallback method.
+type Backend interface {
+	Name() string
+	Probe(ctx context.Context, opts TestOptions) error
+	Run(ctx context.Context, opts TestOptions, paths []string) TestResult
+}
+
+// Planner is implemented by backends that support TestOptions.DryRun.
+type Planner interface {
+	Plan(opts TestOptions, paths []string) TestPlan
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{
+		"fio":    fioBackend{},
+		"dd":     ddBackend{},
+		"winsat": winsatBackend{},
+	}
+)
+
+// RegisterBackend adds or replaces a benchmark engine under its name.
+func RegisterBackend(backend Backend) {
+	if backend == nil {
+		return
+	}
+	name := strings.ToLower(strings.TrimSpace(backend.Name()))
+	if name == "" {
+		return
+	}
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[name] = backend
+}
+
+// LookupBackend returns the engine registered under name.
+func LookupBackend(name string) (Backend, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	backend, exists := backends[strings.ToLower(strings.TrimSpace(name))]
+	return backend, exists
+}
+
+// Backends lists the registered engine names in sorted order.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultFallback is the command line's historical policy: fio falls back
+// to dd and dd to fio; other methods have no fallback.
+func DefaultFallback(method string) []string {
+	switch method {
+	case "fio":
+		return []string{"dd"}
+	case "dd":
+		return []string{"fio"}
+	}
+	return nil
+}
+
+// backendChain 主测试方式在前，去除重复后依次为回退方式
+func backendChain(method string, fallback []string) []string {
+	chain := []string{method}
+	for _, name := range fallback {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "" && !containsMountPoint(chain, name) {
+			chain = append(chain, name)
+		}
+	}
+	return chain
+}
+
+// runBackends 按顺序探测并运行各测试方式，直到得到可用结果；上下文结束后不再尝试回退方式，
+// 主测试方式仍会运行以报告取消状态
+func runBackends(ctx context.Context, opts TestOptions, chain []string, paths []string) TestResult {
+	result := TestResult{Method: chain[0], Status: "unavailable"}
+	var unavailable []string
+	for index, name := range chain {
+		if index > 0 && ctx.Err() != nil {
+			break
+		}
+		backend, exists := LookupBackend(name)
+		if !exists {
+			if index == 0 {
+				result.Text = fmt.Sprintf("Unsupported test method %q.\n", name)
+			}
+			unavailable = append(unavailable, name)
+			continue
+		}
+		// 上下文已结束时探测失败不代表不可用，交给Run报告取消状态
+		if err := backend.Probe(ctx, opts); err != nil && ctx.Err() == nil {
+			loggerInsert(Logger, name+"不可用: "+err.Error())
+			unavailable = append(unavailable, name)
+			continue
+		}
+		result = backend.Run(ctx, opts, paths)
+		result.Method = name
+		if result.Status != "unavailable" {
+			break
+		}
+		unavailable = append(unavailable, name)
+	}
+	result.Unavailable = unavailable
+	return result
+}
+
+// fioBackend 传统FIO表格测试，使用指定的fio或内置fio
+type fioBackend struct{}
+
+func (fioBackend) Name() string { return "fio" }
+
+// Probe 只查找指定的fio或系统/内置fio，不解压也不执行；版本在Run中检查
+func (fioBackend) Probe(ctx context.Context, opts TestOptions) error {
+	if path := explicitFioPath(opts.FioPath); path != "" {
+		_, err := exec.LookPath(path)
+		return err
+	}
+	return lookupBinary("fio", embeddedFioPlatforms)
+}
+
+func (fioBackend) Run(ctx context.Context, opts TestOptions, paths []string) TestResult {
+	config := fioLegacyConfig{size: opts.FioSize, runtime: opts.FioRuntime, blockSizes: opts.FioBlockSizes, ioDepth: opts.FioIODepth, numJobs: opts.FioNumJobs, pattern: opts.DataPattern, filter: opts.DiscoveryFilter, scoreTable: opts.ScoreTable, units: opts.Units, binary: opts.FioPath}
+	fioResult := runFioLegacyPaths(ctx, config, opts.MultiCheck, paths)
+	return TestResult{Status: fioResult.Status, Fio: &fioResult, Text: RenderFioLegacy(opts.Language, fioResult)}
+}
+
+func (fioBackend) Plan(opts TestOptions, paths []string) TestPlan {
+	return planLegacy(opts, "fio", paths)
+}
+
+// ddBackend 传统DD测试，优先使用系统dd
+type ddBackend struct{}
+
+func (ddBackend) Name() string { return "dd" }
+
+func (ddBackend) Probe(ctx context.Context, opts TestOptions) error {
+	return lookupBinary("dd", embeddedDDPlatforms)
+}
+
+func (ddBackend) Run(ctx context.Context, opts TestOptions, paths []string) TestResult {
+	config := ddLegacyConfig{tests: opts.DDTests, dropCaches: opts.DDDropCaches, pattern: opts.DataPattern, filter: opts.DiscoveryFilter, units: opts.Units}
+	ddResult := runDDLegacyPaths(ctx, config, opts.MultiCheck, paths)
+	return TestResult{Status: ddResult.Status, DD: &ddResult, Text: RenderDDLegacy(opts.Language, ddResult)}
+}
+
+func (ddBackend) Plan(opts TestOptions, paths []string) TestPlan {
+	return planLegacy(opts, "dd", paths)
+}
+
+// winsatBackend Windows自带的winsat磁盘测试
+type winsatBackend struct{}
+
+func (winsatBackend) Name() string { return "winsat" }
+
+func (winsatBackend) Probe(ctx context.Context, opts TestOptions) error {
+	if runtime.GOOS != "windows" {
+		return errors.New("winsat requires Windows")
+	}
+	_, err := exec.LookPath("winsat")
+	return err
+}
+
+func (winsatBackend) Run(ctx context.Context, opts TestOptions, paths []string) TestResult {
+	result := TestResult{Status: "ok"}
+	if len(paths) == 0 {
+		result.Text = runWinsat(opts.Language, opts.Units, opts.MultiCheck, "")
+	}
+	for _, path := range paths {
+		result.Text += runWinsat(opts.Language, opts.Units, false, path)
+	}
+	if result.Text == "" {
+		result.Status = "unavailable"
+	} else {
+		result.Text += renderUnitNote(opts.Language, opts.Units)
+	}
+	return result
+}
+
+func (winsatBackend) Plan(opts TestOptions, paths []string) TestPlan {
+	return planLegacy(opts, "winsat", paths)
+}
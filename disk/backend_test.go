@@ -0,0 +1,111 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type fakeBackend struct {
+	name     string
+	probeErr error
+	status   string
+	runs     *[]string
+}
+
+func (b fakeBackend) Name() string { return b.name }
+
+func (b fakeBackend) Probe(context.Context, TestOptions) error { return b.probeErr }
+
+func (b fakeBackend) Run(ctx context.Context, opts TestOptions, paths []string) TestResult {
+	*b.runs = append(*b.runs, b.name)
+	text := ""
+	if b.status != "unavailable" {
+		text = b.name + " table\n"
+	}
+	return TestResult{Status: b.status, Text: text}
+}
+
+func registerFakeBackends(t *testing.T, fakes ...fakeBackend) {
+	t.Helper()
+	for _, fake := range fakes {
+		RegisterBackend(fake)
+	}
+	t.Cleanup(func() {
+		backendsMu.Lock()
+		defer backendsMu.Unlock()
+		for _, fake := range fakes {
+			delete(backends, fake.name)
+		}
+	})
+}
+
+func TestRunTestFollowsFallbackChain(t *testing.T) {
+	var runs []string
+	registerFakeBackends(t,
+		fakeBackend{name: "fake-probe", probeErr: errors.New("missing"), runs: &runs},
+		fakeBackend{name: "fake-empty", status: "unavailable", runs: &runs},
+		fakeBackend{name: "fake-ok", status: "ok", runs: &runs},
+		fakeBackend{name: "fake-late", status: "ok", runs: &runs},
+	)
+	result := RunTest(TestOptions{Method: "fake-probe", Paths: []string{t.TempDir()}, Fallback: []string{"fake-empty", "FAKE-PROBE", "fake-ok", "fake-late"}})
+	if result.Method != "fake-ok" || result.Status != "ok" || result.Text != "fake-ok table\n" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if want := []string{"fake-probe", "fake-empty"}; !reflect.DeepEqual(result.Unavailable, want) {
+		t.Fatalf("unavailable = %v, want %v", result.Unavailable, want)
+	}
+	if want := []string{"fake-empty", "fake-ok"}; !reflect.DeepEqual(runs, want) {
+		t.Fatalf("runs = %v, want %v", runs, want)
+	}
+}
+
+func TestRunTestWithoutFallbackReportsUnavailable(t *testing.T) {
+	var runs []string
+	registerFakeBackends(t, fakeBackend{name: "fake-empty", status: "unavailable", runs: &runs})
+	result := RunTest(TestOptions{Method: "fake-empty", Paths: []string{t.TempDir()}})
+	if result.Method != "fake-empty" || result.Status != "unavailable" || !reflect.DeepEqual(result.Unavailable, []string{"fake-empty"}) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	unknown := RunTest(TestOptions{Method: "nope"})
+	if unknown.Status != "unavailable" || unknown.Text != "Unsupported test method \"nope\".\n" {
+		t.Fatalf("unexpected result %+v", unknown)
+	}
+	if dryRun := RunTest(TestOptions{Method: "fake-empty", DryRun: true}); dryRun.Plan != nil || len(runs) != 1 {
+		t.Fatalf("dry run without a planner: %+v", dryRun)
+	}
+}
+
+func TestBackendRegistry(t *testing.T) {
+	for _, name := range []string{"dd", "fio", "winsat"} {
+		if _, exists := LookupBackend(name); !exists {
+			t.Fatalf("%s is not registered", name)
+		}
+	}
+	if !reflect.DeepEqual(DefaultFallback("fio"), []string{"dd"}) || !reflect.DeepEqual(DefaultFallback("dd"), []string{"fio"}) || DefaultFallback("winsat") != nil {
+		t.Fatal("unexpected default fallback")
+	}
+}
+
+func TestFioProbeDoesNotExtractOrExecute(t *testing.T) {
+	originalGet := getEmbeddedFIO
+	getEmbeddedFIO = func() (string, string, error) {
+		t.Fatal("probe extracted the embedded fio")
+		return "", "", nil
+	}
+	defer func() { getEmbeddedFIO = originalGet }()
+	marker := filepath.Join(t.TempDir(), "ran")
+	script := writeFioScript(t, "fio-3.36; touch "+marker)
+	if err := (fioBackend{}).Probe(context.Background(), TestOptions{FioPath: script}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Fatal("probe executed the explicit fio")
+	}
+	if err := (fioBackend{}).Probe(context.Background(), TestOptions{FioPath: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Fatal("missing explicit fio passed the probe")
+	}
+}
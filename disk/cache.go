@@ -0,0 +1,21 @@
+package disk
+
+import "unsafe"
+
+// directIOAlignment O_DIRECT读取要求的缓冲区、偏移与长度对齐
+const directIOAlignment = 4096
+
+// alignedBuffer 分配按directIOAlignment对齐的缓冲区
+func alignedBuffer(size int) []byte {
+	raw := make([]byte, size+directIOAlignment)
+	offset := 0
+	if remainder := int(uintptr(unsafe.Pointer(&raw[0])) & (directIOAlignment - 1)); remainder != 0 {
+		offset = directIOAlignment - remainder
+	}
+	return raw[offset : offset+size : offset+size]
+}
+
+// roundUpAlignment 将长度向上取整到directIOAlignment的整数倍
+func roundUpAlignment(length int64) int64 {
+	return (length + directIOAlignment - 1) / directIOAlignment * directIOAlignment
+}
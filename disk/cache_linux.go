@@ -0,0 +1,58 @@
+package disk
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// dropPageCache 清除测试文件的页缓存：允许时以root写入drop_caches，否则对文件执行fadvise(DONTNEED)
+func dropPageCache(path string, allowGlobal bool) bool {
+	if allowGlobal && os.Geteuid() == 0 {
+		unix.Sync()
+		if err := os.WriteFile("/proc/sys/vm/drop_caches", []byte("1"), 0o200); err == nil {
+			loggerInsert(Logger, "已通过drop_caches清除页缓存")
+			return true
+		}
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	// 脏页无法被丢弃，先落盘
+	if err := file.Sync(); err != nil {
+		return false
+	}
+	return adviseDontNeed(file)
+}
+
+// adviseDontNeed 对已打开的文件执行fadvise(DONTNEED)，不落盘，供只读调用方使用
+func adviseDontNeed(file *os.File) bool {
+	if err := unix.Fadvise(int(file.Fd()), 0, 0, unix.FADV_DONTNEED); err != nil {
+		loggerInsert(Logger, "fadvise清除页缓存失败: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// openUncachedRead 以O_DIRECT打开文件并试读第一个对齐块，返回读取方式direct；
+// 文件系统不支持时普通打开并尽量清除该文件的页缓存。tmpfs等文件系统上fadvise成功但不会丢弃缓存，
+// 无法确认读取绕过了缓存，因此返回possibly-cached。目标可能是只读扫描的块设备，这里从不落盘，
+// 写入过文件的调用方需自行先Sync
+func openUncachedRead(path string) (*os.File, string, error) {
+	if file, err := os.OpenFile(path, os.O_RDONLY|unix.O_DIRECT, 0); err == nil {
+		if _, err := file.ReadAt(alignedBuffer(directIOAlignment), 0); err == nil || errors.Is(err, io.EOF) {
+			return file, "direct", nil
+		}
+		file.Close()
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, "possibly-cached", err
+	}
+	adviseDontNeed(file)
+	return file, "possibly-cached", nil
+}
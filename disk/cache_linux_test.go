@@ -0,0 +1,20 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDropPageCacheAdvisesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "100MB.test")
+	if err := os.WriteFile(path, make([]byte, 1<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !dropPageCache(path, false) {
+		t.Fatal("fadvise(DONTNEED) on a regular file failed")
+	}
+	if dropPageCache(filepath.Join(filepath.Dir(path), "missing"), false) {
+		t.Fatal("dropping the cache of a missing file reported success")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package disk
+
+import "os"
+
+// dropPageCache 非Linux系统无法可靠清除页缓存
+func dropPageCache(path string, allowGlobal bool) bool {
+	return false
+}
+
+// openUncachedRead 非Linux系统无法绕过页缓存，读取可能命中缓存
+func openUncachedRead(path string) (*os.File, string, error) {
+	file, err := os.Open(path)
+	return file, "possibly-cached", err
+}
@@ -0,0 +1,211 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	gopsutildisk "github.com/shirou/gopsutil/disk"
+)
+
+// capacityBlockMagic prefixes every verification block so a block that was
+// silently remapped to another offset can never pass as the expected one.
+var capacityBlockMagic = []byte("DTCAP001")
+
+const capacityHeaderSize = 24
+
+type CapacityConfig struct {
+	Path        string
+	Fraction    float64
+	BlockSize   int64
+	MaxDuration time.Duration
+}
+
+type CapacityResult struct {
+	SchemaVersion      string  `json:"schema_version"`
+	Status             string  `json:"status"`
+	Fraction           float64 `json:"fraction"`
+	RequestedBytes     int64   `json:"requested_bytes"`
+	WrittenBytes       int64   `json:"written_bytes"`
+	VerifiedBytes      int64   `json:"verified_bytes"`
+	UsableBytes        int64   `json:"usable_bytes"`
+	CorruptBlocks      int64   `json:"corrupt_blocks"`
+	FirstCorruptOffset int64   `json:"first_corrupt_offset"`
+	DurationMS         int64   `json:"duration_ms"`
+	// ReadMode tells whether verification bypassed the page cache: direct
+	// (O_DIRECT), or possibly-cached when O_DIRECT was refused and a fill
+	// smaller than RAM may be verified from memory.
+	ReadMode string `json:"read_mode,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RunCapacityCheck fills a fraction of the free space above the matrix safety
+// reserve with position-tagged blocks and reads them back, in the spirit of
+// f3write/f3read. Media that over-report capacity usually wrap writes around,
+// which shows up as the first block whose tag or payload does not match.
+func RunCapacityCheck(ctx context.Context, config CapacityConfig) (result CapacityResult) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if config.Path == "" {
+		config.Path = os.TempDir()
+	}
+	if config.Fraction <= 0 {
+		config.Fraction = 0.1
+	}
+	if config.Fraction > 1 {
+		config.Fraction = 1
+	}
+	if config.BlockSize <= 0 {
+		config.BlockSize = 1 << 20
+	}
+	// O_DIRECT校验要求块大小按页对齐
+	config.BlockSize = (config.BlockSize + directIOAlignment - 1) / directIOAlignment * directIOAlignment
+	result = CapacityResult{SchemaVersion: "goecs.disk/capacity-v1", Status: "ok", Fraction: config.Fraction, FirstCorruptOffset: -1}
+	started := time.Now()
+	defer func() { result.DurationMS = time.Since(started).Milliseconds() }()
+	if config.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.MaxDuration)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		result.Status, result.Error = matrixStopStatus(err), stableMatrixError(err)
+		return result
+	}
+	usage, err := gopsutildisk.Usage(config.Path)
+	if err != nil {
+		result.Status, result.Error = "unavailable", stableTestPathError(err)
+		return result
+	}
+	if usage.Free > matrixSpaceReserve {
+		requested := int64(float64(usage.Free-matrixSpaceReserve) * config.Fraction)
+		result.RequestedBytes = requested / config.BlockSize * config.BlockSize
+	}
+	if err := ensureMatrixSpace(config.Path, result.RequestedBytes); err != nil {
+		result.Status, result.Error = "unavailable", stableTestPathError(err)
+		return result
+	}
+	testFile, err := os.CreateTemp(config.Path, ".goecs-capacity-*")
+	if err != nil {
+		result.Status, result.Error = "unavailable", stableTestPathError(err)
+		return result
+	}
+	markArtifact(testFile.Name())
+	defer removeArtifact(testFile.Name())
+	defer testFile.Close()
+	seed, err := capacitySeed()
+	if err != nil {
+		result.Status, result.Error = "error", "seed_unavailable"
+		return result
+	}
+	written, writeErr := writeCapacityBlocks(ctx, testFile, result.RequestedBytes, config.BlockSize, seed)
+	result.WrittenBytes = written
+	if writeErr == nil {
+		writeErr = testFile.Sync()
+	}
+	if err := ctx.Err(); err != nil {
+		result.Status, result.Error = matrixStopStatus(err), stableMatrixError(err)
+		return result
+	}
+	// 重新以绕过页缓存的方式打开，否则小于内存的写入会直接从缓存校验通过
+	readFile, readMode, readErr := openUncachedRead(testFile.Name())
+	if readErr != nil {
+		result.Status, result.Error = "error", "read_failed"
+		return result
+	}
+	defer readFile.Close()
+	result.ReadMode = readMode
+	verified, firstCorrupt, corruptBlocks, readErr := verifyCapacityBlocks(ctx, readFile, written, config.BlockSize, seed)
+	result.VerifiedBytes, result.CorruptBlocks, result.FirstCorruptOffset = verified, corruptBlocks, firstCorrupt
+	result.UsableBytes = verified
+	if firstCorrupt >= 0 {
+		result.UsableBytes = firstCorrupt
+	}
+	switch {
+	case ctx.Err() != nil:
+		result.Status, result.Error = matrixStopStatus(ctx.Err()), stableMatrixError(ctx.Err())
+	case corruptBlocks > 0:
+		result.Status, result.Error = "corrupted", "capacity_mismatch"
+	case writeErr != nil:
+		result.Status, result.Error = "error", "write_failed"
+	case readErr != nil:
+		result.Status, result.Error = "error", "read_failed"
+	}
+	return result
+}
+
+func capacitySeed() (uint64, error) {
+	var raw [8]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(raw[:]) | 1, nil
+}
+
+// fillCapacityBlock derives the whole block from the run seed and its offset,
+// so verification needs no second copy of the written data.
+func fillCapacityBlock(block []byte, offset int64, seed uint64) {
+	copy(block, capacityBlockMagic)
+	binary.LittleEndian.PutUint64(block[8:], uint64(offset))
+	binary.LittleEndian.PutUint64(block[16:], seed)
+	state := seed ^ (uint64(offset) * 0x9e3779b97f4a7c15)
+	if state == 0 {
+		state = seed
+	}
+	for index := capacityHeaderSize; index < len(block); index += 8 {
+		state ^= state << 13
+		state ^= state >> 7
+		state ^= state << 17
+		var word [8]byte
+		binary.LittleEndian.PutUint64(word[:], state)
+		copy(block[index:], word[:])
+	}
+}
+
+func writeCapacityBlocks(ctx context.Context, file *os.File, requested, blockSize int64, seed uint64) (int64, error) {
+	block := make([]byte, blockSize)
+	var written int64
+	for written+blockSize <= requested {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		fillCapacityBlock(block, written, seed)
+		if _, err := file.WriteAt(block, written); err != nil {
+			return written, err
+		}
+		written += blockSize
+	}
+	return written, nil
+}
+
+// verifyCapacityBlocks returns the number of bytes read back, the offset of
+// the first mismatching block (-1 when none) and the count of bad blocks.
+func verifyCapacityBlocks(ctx context.Context, file *os.File, written, blockSize int64, seed uint64) (int64, int64, int64, error) {
+	expected := make([]byte, blockSize)
+	actual := alignedBuffer(int(blockSize))
+	var verified, corruptBlocks int64
+	firstCorrupt := int64(-1)
+	for verified+blockSize <= written {
+		if err := ctx.Err(); err != nil {
+			return verified, firstCorrupt, corruptBlocks, err
+		}
+		fillCapacityBlock(expected, verified, seed)
+		if _, err := file.ReadAt(actual, verified); err != nil && !errors.Is(err, io.EOF) {
+			return verified, firstCorrupt, corruptBlocks, err
+		}
+		if !bytes.Equal(expected, actual) {
+			corruptBlocks++
+			if firstCorrupt < 0 {
+				firstCorrupt = verified
+			}
+		}
+		verified += blockSize
+	}
+	return verified, firstCorrupt, corruptBlocks, nil
+}
@@ -0,0 +1,82 @@
+package disk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestVerifyCapacityBlocksFindsWrappedBlock(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), ".goecs-capacity-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	const blockSize = 4096
+	written, err := writeCapacityBlocks(context.Background(), file, 8*blockSize, blockSize, 7)
+	if err != nil || written != 8*blockSize {
+		t.Fatalf("writeCapacityBlocks() = %d, %v", written, err)
+	}
+	verified, first, corrupt, err := verifyCapacityBlocks(context.Background(), file, written, blockSize, 7)
+	if err != nil || verified != written || first != -1 || corrupt != 0 {
+		t.Fatalf("clean verify = %d %d %d %v", verified, first, corrupt, err)
+	}
+	// Simulate a counterfeit device wrapping offset 3 back onto block 0.
+	wrapped := make([]byte, blockSize)
+	fillCapacityBlock(wrapped, 0, 7)
+	if _, err := file.WriteAt(wrapped, 3*blockSize); err != nil {
+		t.Fatal(err)
+	}
+	_, first, corrupt, err = verifyCapacityBlocks(context.Background(), file, written, blockSize, 7)
+	if err != nil || first != 3*blockSize || corrupt != 1 {
+		t.Fatalf("wrapped verify first=%d corrupt=%d err=%v", first, corrupt, err)
+	}
+}
+
+func TestRunCapacityCheckRejectsUnsafePaths(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	for _, path := range []string{missing, "/dev"} {
+		result := RunCapacityCheck(context.Background(), CapacityConfig{Path: path, Fraction: 0.01})
+		if result.Status != "unavailable" || result.Error == "" || result.WrittenBytes != 0 {
+			t.Fatalf("unexpected result for %s: %+v", path, result)
+		}
+	}
+}
+
+func TestRunCapacityCheckStopsWhenCanceled(t *testing.T) {
+	directory := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := RunCapacityCheck(ctx, CapacityConfig{Path: directory, Fraction: 0.5})
+	if result.Status != "canceled" {
+		t.Fatalf("unexpected canceled result: %+v", result)
+	}
+	assertDirectoryEmpty(t, directory)
+}
+
+func TestCapacityVerificationBypassesPageCache(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), ".goecs-capacity-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	const blockSize = 8192
+	written, err := writeCapacityBlocks(context.Background(), file, 4*blockSize, blockSize, 11)
+	if err != nil || file.Sync() != nil {
+		t.Fatalf("writeCapacityBlocks() = %d, %v", written, err)
+	}
+	readFile, readMode, err := openUncachedRead(file.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer readFile.Close()
+	if runtime.GOOS == "linux" && readMode == "possibly-cached" {
+		t.Fatalf("verification on Linux may be served from the page cache")
+	}
+	verified, first, corrupt, err := verifyCapacityBlocks(context.Background(), readFile, written, blockSize, 11)
+	if err != nil || verified != written || first != -1 || corrupt != 0 {
+		t.Fatalf("uncached verify (%s) = %d %d %d %v", readMode, verified, first, corrupt, err)
+	}
+}
@@ -1,6 +1,7 @@
 package disk
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/shirou/gopsutil/disk"
@@ -26,8 +27,17 @@ var (
 
 // TestPathInfo 测试路径信息结构体
 type TestPathInfo struct {
-	Devices     []string // 设备列表
-	MountPoints []string // 挂载点列表
+	Devices     []string     // 设备列表
+	MountPoints []string     // 挂载点列表
+	Aliases     []MountAlias // 因与已选挂载点共用设备而跳过的挂载点
+}
+
+// MountAlias is a writable mount skipped because it shares the filesystem
+// or, with OnePerDisk, the physical disk of the selected mount AliasOf.
+type MountAlias struct {
+	MountPoint string `json:"mount_point"`
+	AliasOf    string `json:"alias_of"`
+	Reason     string `json:"reason"`
 }
 
 // shouldExcludeFsType 检查是否应该排除文件系统类型
@@ -81,9 +91,75 @@ func containsMountPoint(mountPoints []string, mountPoint string) bool {
 	return false
 }
 
+// MountInfo describes one mounted filesystem seen by test-path discovery.
+// Excluded is empty for mounts that are used as test paths and otherwise
+// names the rule that skipped the mount.
+type MountInfo struct {
+	MountPoint string   `json:"mount_point"`
+	Device     string   `json:"device"`
+	FsType     string   `json:"fs_type"`
+	Options    []string `json:"options,omitempty"`
+	TotalBytes uint64   `json:"total_bytes"`
+	FreeBytes  uint64   `json:"free_bytes"`
+	UsedBytes  uint64   `json:"used_bytes"`
+	FreeInodes uint64   `json:"free_inodes"`
+	// DeviceID is the st_dev major:minor of the filesystem and Disk the
+	// whole disk holding it, when they can be resolved.
+	DeviceID string `json:"device_id,omitempty"`
+	Disk     string `json:"disk,omitempty"`
+	// Stack is the block-device stack below the filesystem, when resolved.
+	Stack    *StorageStack `json:"stack,omitempty"`
+	ReadOnly bool          `json:"read_only"`
+	Writable bool          `json:"writable"`
+	Excluded string        `json:"excluded,omitempty"`
+	// AliasOf names the selected mount a duplicate_device or duplicate_disk
+	// mount shares its device with.
+	AliasOf string `json:"alias_of,omitempty"`
+}
+
+// DiscoverMounts returns every mounted filesystem with its capacity and the
+// reason it is or is not used as a test path; the mounts with an empty
+// Excluded are exactly the ones DiscoverTestPaths returns.
+func DiscoverMounts() ([]MountInfo, error) {
+	return discoverMounts(DiscoveryFilter{})
+}
+
+// DiscoverMountsWithFilter is DiscoverMounts with filter applied on top of
+// the built-in rules.
+func DiscoverMountsWithFilter(filter DiscoveryFilter) ([]MountInfo, error) {
+	return discoverMounts(filter)
+}
+
 // getTestPaths 获取可用的测试路径,返回设备和挂载点列表
-func getTestPaths() (TestPathInfo, error) {
+func getTestPaths(filter DiscoveryFilter) (TestPathInfo, error) {
+	return getTestPathsWith(filter, isWritableMountpoint)
+}
+
+func getTestPathsWith(filter DiscoveryFilter, writable func(string) bool) (TestPathInfo, error) {
 	var pathInfo TestPathInfo
+	mounts, err := discoverMountsWith(filter, writable)
+	for _, mount := range mounts {
+		if mount.Excluded == "" {
+			pathInfo.Devices = append(pathInfo.Devices, mount.Device)
+			pathInfo.MountPoints = append(pathInfo.MountPoints, mount.MountPoint)
+		} else if mount.AliasOf != "" {
+			pathInfo.Aliases = append(pathInfo.Aliases, MountAlias{MountPoint: mount.MountPoint, AliasOf: mount.AliasOf, Reason: mount.Excluded})
+		}
+	}
+	return pathInfo, err
+}
+
+// discoverMounts 枚举所有分区并记录容量信息与排除原因
+func discoverMounts(filter DiscoveryFilter) ([]MountInfo, error) {
+	return discoverMountsWith(filter, isWritableMountpoint)
+}
+
+// discoverMountsWith 使用指定的可写检查枚举分区，预演模式下不创建探测文件
+func discoverMountsWith(filter DiscoveryFilter, writable func(string) bool) ([]MountInfo, error) {
+	rules, err := filter.compile()
+	if err != nil {
+		return nil, err
+	}
 	parts, err := disk.Partitions(false)
 	if EnableLoger {
 		InitLogger()
@@ -93,63 +169,162 @@ func getTestPaths() (TestPathInfo, error) {
 			Logger.Info("路径: " + part.Mountpoint + ", 设备: " + part.Device + ", 文件系统: " + part.Fstype)
 		}
 	}
-	if err == nil {
-		for _, f := range parts {
-			fsType := strings.ToLower(f.Fstype)
-			// 检查文件系统类型是否应该被排除
-			if shouldExcludeFsType(fsType) {
-				loggerInsert(Logger, "排除文件系统类型: "+f.Fstype+", 设备: "+f.Device+", 挂载点: "+f.Mountpoint)
-				continue
-			}
-			// 检查挂载点是否应该被排除
-			if shouldExcludeMountPoint(f.Mountpoint) {
-				loggerInsert(Logger, "排除挂载点: "+f.Mountpoint+", 设备: "+f.Device)
-				continue
+	if err != nil {
+		return nil, err
+	}
+	mounts := make([]MountInfo, 0, len(parts))
+	var selected []string
+	// 已选挂载点按文件系统设备号、挂载源设备和整盘索引
+	filesystems := make(map[string]string)
+	disks := make(map[string]string)
+	for _, f := range parts {
+		mount := MountInfo{MountPoint: f.Mountpoint, Device: f.Device, FsType: f.Fstype}
+		if f.Opts != "" {
+			mount.Options = strings.Split(f.Opts, ",")
+		}
+		mount.ReadOnly = slices.Contains(mount.Options, "ro")
+		mount.Excluded = filterMountReason(f, rules)
+		if mount.Excluded != "excluded_fs_type" {
+			if usage, err := disk.Usage(f.Mountpoint); err == nil {
+				mount.TotalBytes, mount.FreeBytes, mount.UsedBytes = usage.Total, usage.Free, usage.Used
+				mount.FreeInodes = usage.InodesFree
 			}
-			// 设备过滤逻辑
-			if strings.Contains(f.Device, "vda") ||
-				strings.Contains(f.Device, "snap") ||
-				strings.Contains(f.Device, "loop") {
-				loggerInsert(Logger, "排除设备类型: "+f.Device+", 挂载点: "+f.Mountpoint)
-				continue
+			mount.DeviceID, mount.Disk = mountDevice(f.Mountpoint, f.Device)
+			if stack := storageStack(f.Mountpoint, f.Device); len(stack.Layers) > 0 {
+				stack.FsType = f.Fstype
+				mount.Stack = &stack
 			}
-			// 优先选择期望的文件系统类型
-			if isExpectedFsType(fsType) {
-				if isWritableMountpoint(f.Mountpoint) {
-					// 检查挂载点是否已存在，避免重复添加
-					if !containsMountPoint(pathInfo.MountPoints, f.Mountpoint) {
-						pathInfo.Devices = append(pathInfo.Devices, f.Device)
-						pathInfo.MountPoints = append(pathInfo.MountPoints, f.Mountpoint)
-						loggerInsert(Logger, "添加期望文件系统可写分区: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
-					} else {
-						loggerInsert(Logger, "跳过重复挂载点: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
-					}
-				} else {
-					loggerInsert(Logger, "期望文件系统但不可写: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
+		}
+		fsKeys := filesystemKeys(mount)
+		if mount.Excluded == "" && mount.TotalBytes < rules.MinSizeBytes {
+			mount.Excluded = "below_min_size"
+			loggerInsert(Logger, "挂载点容量过小: "+f.Mountpoint+", 设备: "+f.Device)
+		}
+		if mount.Excluded == "" {
+			switch {
+			case mount.ReadOnly:
+				mount.Excluded = "read_only"
+				loggerInsert(Logger, "只读挂载点: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
+			case !writable(f.Mountpoint):
+				mount.Excluded = "not_writable"
+				loggerInsert(Logger, "挂载点不可写: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
+			case containsMountPoint(selected, f.Mountpoint):
+				mount.Writable = true
+				mount.Excluded = "duplicate_mount_point"
+				loggerInsert(Logger, "跳过重复挂载点: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
+			case firstMatch(filesystems, fsKeys) != "":
+				mount.Writable = true
+				mount.Excluded, mount.AliasOf = "duplicate_device", firstMatch(filesystems, fsKeys)
+				loggerInsert(Logger, "跳过同一设备的挂载点: "+f.Mountpoint+", 与 "+mount.AliasOf+" 相同, 设备: "+f.Device)
+			case rules.OnePerDisk && mount.Disk != "" && disks[mount.Disk] != "":
+				mount.Writable = true
+				mount.Excluded, mount.AliasOf = "duplicate_disk", disks[mount.Disk]
+				loggerInsert(Logger, "跳过同一磁盘的挂载点: "+f.Mountpoint+", 与 "+mount.AliasOf+" 相同, 磁盘: "+mount.Disk)
+			default:
+				mount.Writable = true
+				selected = append(selected, f.Mountpoint)
+				for _, key := range fsKeys {
+					filesystems[key] = f.Mountpoint
 				}
-			} else {
-				// 其他文件系统类型，如果可写也加入
-				if isWritableMountpoint(f.Mountpoint) {
-					// 检查挂载点是否已存在，避免重复添加
-					if !containsMountPoint(pathInfo.MountPoints, f.Mountpoint) {
-						pathInfo.Devices = append(pathInfo.Devices, f.Device)
-						pathInfo.MountPoints = append(pathInfo.MountPoints, f.Mountpoint)
-						loggerInsert(Logger, "添加其他可写分区: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
-					} else {
-						loggerInsert(Logger, "跳过重复挂载点: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
-					}
+				if mount.Disk != "" {
+					disks[mount.Disk] = f.Mountpoint
+				}
+				if isExpectedFsType(f.Fstype) {
+					loggerInsert(Logger, "添加期望文件系统可写分区: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
 				} else {
-					loggerInsert(Logger, "其他文件系统但不可写: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
+					loggerInsert(Logger, "添加其他可写分区: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
 				}
 			}
 		}
+		mounts = append(mounts, mount)
 	}
-	return pathInfo, err
+	return mounts, nil
+}
+
+// renderMountAliases 输出多盘测试中因共用设备而跳过的挂载点
+func renderMountAliases(language string, aliases []MountAlias) string {
+	var text string
+	for _, alias := range aliases {
+		if alias.Reason == "duplicate_disk" {
+			text += localizedText(language, alias.MountPoint+" 与 "+alias.AliasOf+" 位于同一磁盘，未重复测试",
+				alias.MountPoint+" is on the same disk as "+alias.AliasOf+", not tested again") + "\n"
+		} else {
+			text += localizedText(language, alias.MountPoint+" 与 "+alias.AliasOf+" 为同一设备，未重复测试",
+				alias.MountPoint+" is the same device as "+alias.AliasOf+", not tested again") + "\n"
+		}
+	}
+	return text
+}
+
+// filesystemKeys 返回标识挂载点所在文件系统的键：设备号与 /dev 下的挂载源
+// 绑定挂载共用设备号，btrfs子卷设备号不同但挂载源相同
+func filesystemKeys(mount MountInfo) []string {
+	var keys []string
+	if mount.DeviceID != "" {
+		keys = append(keys, "dev:"+mount.DeviceID)
+	}
+	if strings.HasPrefix(mount.Device, "/dev/") {
+		keys = append(keys, "source:"+mount.Device)
+	}
+	return keys
+}
+
+func firstMatch(index map[string]string, keys []string) string {
+	for _, key := range keys {
+		if mountPoint := index[key]; mountPoint != "" {
+			return mountPoint
+		}
+	}
+	return ""
+}
+
+// filterMountReason 先应用内置规则再应用自定义规则，命中包含规则时不排除
+func filterMountReason(f disk.PartitionStat, rules mountFilter) string {
+	if rules.includes(f) {
+		loggerInsert(Logger, "按自定义规则包含: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
+		return ""
+	}
+	if reason := excludeMountReason(f); reason != "" {
+		return reason
+	}
+	if reason := rules.excludeReason(f); reason != "" {
+		loggerInsert(Logger, "按自定义规则排除: "+f.Mountpoint+", 设备: "+f.Device+", 文件系统: "+f.Fstype)
+		return reason
+	}
+	return ""
+}
+
+// excludeMountReason 按文件系统类型、挂载点和设备名筛选，返回排除原因
+func excludeMountReason(f disk.PartitionStat) string {
+	// 检查文件系统类型是否应该被排除
+	if shouldExcludeFsType(f.Fstype) {
+		loggerInsert(Logger, "排除文件系统类型: "+f.Fstype+", 设备: "+f.Device+", 挂载点: "+f.Mountpoint)
+		return "excluded_fs_type"
+	}
+	// 检查挂载点是否应该被排除
+	if shouldExcludeMountPoint(f.Mountpoint) {
+		loggerInsert(Logger, "排除挂载点: "+f.Mountpoint+", 设备: "+f.Device)
+		return "excluded_mount_point"
+	}
+	// 设备过滤逻辑
+	if strings.Contains(f.Device, "vda") ||
+		strings.Contains(f.Device, "snap") ||
+		strings.Contains(f.Device, "loop") {
+		loggerInsert(Logger, "排除设备类型: "+f.Device+", 挂载点: "+f.Mountpoint)
+		return "excluded_device"
+	}
+	return ""
 }
 
 // DiscoverTestPaths exposes the same writable mount discovery used by the
 // standalone legacy CLI so orchestrators can run an equivalent multi-disk
 // matrix without duplicating platform-specific filtering.
 func DiscoverTestPaths() (TestPathInfo, error) {
-	return getTestPaths()
+	return getTestPaths(DiscoveryFilter{})
+}
+
+// DiscoverTestPathsWithFilter is DiscoverTestPaths with filter applied on top
+// of the built-in rules.
+func DiscoverTestPathsWithFilter(filter DiscoveryFilter) (TestPathInfo, error) {
+	return getTestPaths(filter)
 }
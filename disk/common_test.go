@@ -0,0 +1,65 @@
+package disk
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+func TestExcludeMountReason(t *testing.T) {
+	for _, test := range []struct {
+		part disk.PartitionStat
+		want string
+	}{
+		{disk.PartitionStat{Device: "proc", Mountpoint: "/proc", Fstype: "proc"}, "excluded_fs_type"},
+		{disk.PartitionStat{Device: "/dev/sdb1", Mountpoint: "/var/lib/docker", Fstype: "ext4"}, "excluded_mount_point"},
+		{disk.PartitionStat{Device: "/dev/loop3", Mountpoint: "/mnt/image", Fstype: "ext4"}, "excluded_device"},
+		{disk.PartitionStat{Device: "/dev/sdb1", Mountpoint: "/data", Fstype: "xfs"}, ""},
+	} {
+		if got := excludeMountReason(test.part); got != test.want {
+			t.Fatalf("excludeMountReason(%+v) = %q, want %q", test.part, got, test.want)
+		}
+	}
+}
+
+func TestDiscoverMountsMatchesTestPaths(t *testing.T) {
+	mounts, err := DiscoverMounts()
+	if err != nil {
+		t.Skipf("partitions unavailable: %v", err)
+	}
+	pathInfo, err := DiscoverTestPaths()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var selected []string
+	for _, mount := range mounts {
+		if mount.Excluded == "" {
+			selected = append(selected, mount.MountPoint)
+			if !mount.Writable || mount.ReadOnly {
+				t.Fatalf("selected mount is not writable: %+v", mount)
+			}
+		}
+	}
+	if len(selected) != len(pathInfo.MountPoints) {
+		t.Fatalf("DiscoverMounts selected %v, DiscoverTestPaths %v", selected, pathInfo.MountPoints)
+	}
+}
+
+func TestFilesystemKeysAndAliases(t *testing.T) {
+	keys := filesystemKeys(MountInfo{Device: "/dev/sda2", DeviceID: "0:45"})
+	index := map[string]string{"source:/dev/sda2": "/"}
+	if len(keys) != 2 || firstMatch(index, keys) != "/" {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+	if keys := filesystemKeys(MountInfo{Device: "nas:/export"}); len(keys) != 0 {
+		t.Fatalf("network source used as a key: %v", keys)
+	}
+	text := renderMountAliases("en", []MountAlias{
+		{MountPoint: "/home", AliasOf: "/", Reason: "duplicate_device"},
+		{MountPoint: "/data", AliasOf: "/", Reason: "duplicate_disk"},
+	})
+	want := "/home is the same device as /, not tested again\n/data is on the same disk as /, not tested again\n"
+	if text != want {
+		t.Fatalf("renderMountAliases = %q, want %q", text, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -26,17 +27,19 @@ func generateDDTestHeader(language string, actualTestPaths []string) string {
 	}
 	var header string
 	if language == "en" {
-		header = fmt.Sprintf("%-*s    %-15s    %-27s    %-27s\n",
+		header = fmt.Sprintf("%-*s    %-15s    %-27s    %-27s    %s\n",
 			mountPointsWidth, "Test Path",
 			"Block Size",
 			"Direct Write(IOPS)",
-			"Direct Read(IOPS)")
+			"Direct Read(IOPS)",
+			"Read Mode")
 	} else {
-		header = fmt.Sprintf("%-*s    %-15s    %-27s    %-27s\n",
+		header = fmt.Sprintf("%-*s    %-15s    %-27s    %-27s    %s\n",
 			mountPointsWidth, "测试路径",
 			"块大小",
 			"直接写入(IOPS)",
-			"直接读取(IOPS)")
+			"直接读取(IOPS)",
+			"读取方式")
 	}
 	return header
 }
@@ -46,27 +49,288 @@ func DDTest(language string, enableMultiCheck bool, testPath string) string {
 	return DDTestContext(context.Background(), language, enableMultiCheck, testPath)
 }
 
+// DDMeasurement is one parsed dd transfer. ReportedSpeed keeps dd's own
+// speed text when it printed one; the legacy table renders BytesPerSecond
+// in the selected unit system instead.
+type DDMeasurement struct {
+	Bytes          int64   `json:"bytes"`
+	Seconds        float64 `json:"seconds"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+	IOPS           float64 `json:"iops"`
+	ReportedSpeed  string  `json:"reported_speed,omitempty"`
+}
+
+// DDResult is one legacy DD row: a direct write followed by a read of the
+// same file. WriteStatus and ReadStatus are ok, failed, unparsable or
+// canceled; both stay empty when the test could not start.
+type DDResult struct {
+	Path        string        `json:"path"`
+	Device      string        `json:"device"`
+	BlockSize   string        `json:"block_size"`
+	BlockName   string        `json:"block_name"`
+	WriteStatus string        `json:"write_status"`
+	ReadStatus  string        `json:"read_status"`
+	Write       DDMeasurement `json:"write"`
+	Read        DDMeasurement `json:"read"`
+	// ReadMode tells how the read bypassed the page cache: direct
+	// (iflag=direct), cache-dropped or possibly-cached.
+	ReadMode      string `json:"read_mode,omitempty"`
+	DataPattern   string `json:"data_pattern,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// DDLegacyResult is the typed outcome of the legacy DD benchmark.
+type DDLegacyResult struct {
+	Status  string     `json:"status"`
+	Results []DDResult `json:"results,omitempty"`
+	Error   string     `json:"error,omitempty"`
+	// Aliases lists mounts a multi-disk run skipped as duplicates.
+	Aliases []MountAlias `json:"aliases,omitempty"`
+	// Stacks maps tested paths on LVM, dm-crypt or md devices to the
+	// device stack below them.
+	Stacks map[string]StorageStack `json:"stacks,omitempty"`
+	// Throttles maps tested paths to the cgroup v2 I/O settings limiting
+	// them.
+	Throttles map[string]IOThrottle `json:"throttles,omitempty"`
+	// Units is the unit system RenderDDLegacy uses for bandwidth.
+	Units UnitFormat `json:"units"`
+}
+
+// DDTestSpec is one legacy DD row: BlockSize is passed to dd as bs= and
+// TotalSize is the amount written and read back, such as {"4k", "100M"}.
+// Sizes use binary units; TotalSize is still shrunk when free space is short.
+type DDTestSpec struct {
+	BlockSize string `json:"block_size"`
+	TotalSize string `json:"total_size"`
+}
+
+// defaultDDTests 默认的两组DD测试：100MB-4K与1GB-1M（1000个1M块）
+var defaultDDTests = []DDTestSpec{{BlockSize: "4k", TotalSize: "100M"}, {BlockSize: "1M", TotalSize: "1000M"}}
+
+var ddBlockSizePattern = regexp.MustCompile(`^[0-9]+[kKmMgG]?$`)
+
+// ParseDDTests parses a comma-separated list of block:total pairs such as
+// "4k:100M,1M:1G".
+func ParseDDTests(value string) ([]DDTestSpec, error) {
+	var specs []DDTestSpec
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		blockSize, totalSize, found := strings.Cut(item, ":")
+		if !found {
+			return nil, fmt.Errorf("dd test %q must be block:total", item)
+		}
+		spec := DDTestSpec{BlockSize: strings.TrimSpace(blockSize), TotalSize: strings.TrimSpace(totalSize)}
+		if _, err := newDDTestPlan(spec); err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no dd tests specified")
+	}
+	return specs, nil
+}
+
+// ddTestPlan 单组DD测试的块大小与总字节数
+type ddTestPlan struct {
+	blockSize  string
+	blockBytes uint64
+	totalBytes uint64
+}
+
+func newDDTestPlan(spec DDTestSpec) (ddTestPlan, error) {
+	plan := ddTestPlan{blockSize: spec.BlockSize}
+	if !ddBlockSizePattern.MatchString(spec.BlockSize) {
+		return plan, fmt.Errorf("invalid dd block size %q", spec.BlockSize)
+	}
+	blockBytes, err := parseSizeBytes(spec.BlockSize)
+	if err != nil || blockBytes == 0 {
+		return plan, fmt.Errorf("invalid dd block size %q", spec.BlockSize)
+	}
+	totalBytes, err := parseSizeBytes(spec.TotalSize)
+	if err != nil || totalBytes < 1024*1024 || totalBytes < blockBytes {
+		return plan, fmt.Errorf("dd total size %q must be at least 1M and one block", spec.TotalSize)
+	}
+	// dd的count按块数计算，不能整除时实际写入量会少于声明的总大小
+	if totalBytes%blockBytes != 0 {
+		return plan, fmt.Errorf("dd total size %q must be a multiple of the block size %q", spec.TotalSize, spec.BlockSize)
+	}
+	plan.blockBytes, plan.totalBytes = blockBytes, totalBytes
+	return plan, nil
+}
+
+// sizeLabel 按MB/GB显示总大小，1000MB与1024MB都显示为1GB；不是整MB时按KB或字节显示实际大小
+func (plan ddTestPlan) sizeLabel() string {
+	sizeMB := plan.totalBytes / (1024 * 1024)
+	switch {
+	case plan.totalBytes%(1024*1024) != 0 && plan.totalBytes%1024 == 0:
+		return fmt.Sprintf("%dKB", plan.totalBytes/1024)
+	case plan.totalBytes%(1024*1024) != 0:
+		return fmt.Sprintf("%dB", plan.totalBytes)
+	case sizeMB >= 1024 && sizeMB%1024 == 0:
+		return fmt.Sprintf("%dGB", sizeMB/1024)
+	case sizeMB >= 1000 && sizeMB%1000 == 0:
+		return fmt.Sprintf("%dGB", sizeMB/1000)
+	default:
+		return fmt.Sprintf("%dMB", sizeMB)
+	}
+}
+
+func (plan ddTestPlan) name() string {
+	return plan.sizeLabel() + "-" + strings.ToUpper(plan.blockSize) + " Block"
+}
+
+func (plan ddTestPlan) file() string {
+	return plan.sizeLabel() + ".test"
+}
+
+func (plan ddTestPlan) count() string {
+	return strconv.FormatUint(plan.totalBytes/plan.blockBytes, 10)
+}
+
 // DDTestContext runs the legacy DD benchmark while honoring cancellation and
 // deadlines from API/GUI callers. DDTest keeps the original standalone API.
 func DDTestContext(ctx context.Context, language string, enableMultiCheck bool, testPath string) string {
+	return RenderDDLegacy(language, DDTestResults(ctx, enableMultiCheck, testPath))
+}
+
+// RenderDDLegacy renders a typed DD result as the localized table returned
+// by DDTest.
+func RenderDDLegacy(language string, result DDLegacyResult) string {
+	if len(result.Results) == 0 && result.Error == "test_path_create_failed" {
+		return localizedText(language, "创建测试路径失败", "Unable to create test path") + "\n"
+	}
+	if result.Error == "invalid_dd_test" {
+		return localizedText(language, "DD测试参数无效", "Invalid DD test parameters") + "\n"
+	}
+	blocks := make([]string, 0, len(result.Results))
+	for _, row := range result.Results {
+		blocks = append(blocks, renderDDRow(language, result.Units, row))
+	}
+	text := renderLegacyResults(language, blocks, generateDDTestHeader)
+	if text != "" {
+		text += renderUnitNote(language, result.Units)
+	}
+	return text + renderMountAliases(language, result.Aliases) +
+		renderStorageStacks(language, result.Stacks) + renderThrottles(language, result.Units, result.Throttles)
+}
+
+// renderDDRow 生成单行DD测试输出，未能开始的测试不输出
+func renderDDRow(language string, units UnitFormat, row DDResult) string {
+	if row.WriteStatus == "" && row.ReadStatus == "" {
+		return ""
+	}
+	deviceWidth := getMountPointColumnWidth(row.Device)
+	if deviceWidth < 15 {
+		deviceWidth = 15
+	}
+	cell := func(status string, measurement DDMeasurement, failed string) string {
+		switch status {
+		case "ok":
+			return renderDDMeasurement(measurement, true, units)
+		case "unparsable":
+			return localizedText(language, "无法解析结果", "Unable to parse result")
+		case "canceled":
+			return localizedText(language, "读取已取消", "Read canceled")
+		default:
+			return failed
+		}
+	}
+	result := fmt.Sprintf("%-*s    %-15s    ", deviceWidth, row.Device, row.BlockName)
+	result += fmt.Sprintf("%-30s    ", cell(row.WriteStatus, row.Write, localizedText(language, "写入失败", "Write failed")))
+	result += fmt.Sprintf("%-30s    %s\n", cell(row.ReadStatus, row.Read, localizedText(language, "读取失败", "Read failed")), ddReadModeLabel(language, row.ReadMode))
+	return result
+}
+
+// ddReadModeLabel 本地化读取方式
+func ddReadModeLabel(language, readMode string) string {
+	switch readMode {
+	case "direct":
+		return localizedText(language, "直接读取", "direct")
+	case "cache-dropped":
+		return localizedText(language, "已清除缓存", "cache dropped")
+	case "possibly-cached":
+		return localizedText(language, "可能命中缓存", "possibly cached")
+	}
+	return ""
+}
+
+// ddLegacyStatus 按已输出与失败的行数给出整体状态
+func ddLegacyStatus(results []DDResult) string {
+	rendered, failed := 0, 0
+	for _, row := range results {
+		if row.WriteStatus != "" || row.ReadStatus != "" {
+			rendered++
+		}
+		if row.FailureReason != "" {
+			failed++
+		}
+	}
+	return legacyResultStatus(rendered, failed)
+}
+
+// DDTestResults runs the legacy DD benchmark and returns typed measurements
+// instead of the rendered table.
+func DDTestResults(ctx context.Context, enableMultiCheck bool, testPath string) DDLegacyResult {
+	return runDDLegacy(ctx, ddLegacyConfig{}, enableMultiCheck, testPath)
+}
+
+// ddLegacyConfig carries the tunable parts of the legacy DD run; zero values
+// keep the historical defaults.
+type ddLegacyConfig struct {
+	tests []DDTestSpec
+	// dropCaches allows writing /proc/sys/vm/drop_caches as root when a
+	// direct read is not possible; the per-file fadvise is always tried.
+	dropCaches bool
+	pattern    DataPattern
+	filter     DiscoveryFilter
+	units      UnitFormat
+}
+
+// writePattern 返回实际写入的数据模式，默认为零数据
+func (config ddLegacyConfig) writePattern() DataPattern {
+	if config.pattern.Kind == "" {
+		return DataPattern{Kind: "zeros"}
+	}
+	return config.pattern
+}
+
+func runDDLegacy(ctx context.Context, config ddLegacyConfig, enableMultiCheck bool, testPath string) (result DDLegacyResult) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	result.Units = config.units.resolved()
+	defer func() {
+		for _, row := range result.Results {
+			if row.WriteStatus == "ok" {
+				checkThrottle(result.Throttles, row.Path, "write", row.Write.BytesPerSecond, row.Write.IOPS)
+			}
+			if row.ReadStatus == "ok" {
+				checkThrottle(result.Throttles, row.Path, "read", row.Read.BytesPerSecond, row.Read.IOPS)
+			}
+		}
+		result.Status = ddLegacyStatus(result.Results)
+		if ctx.Err() != nil {
+			result.Status, result.Error = matrixStopStatus(ctx.Err()), stableMatrixError(ctx.Err())
+		}
+	}()
 	if ctx.Err() != nil {
-		return ""
+		return result
 	}
-	var actualResults []string
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
 		Logger.Info("开始DD测试硬盘IO")
 	}
-	pathInfo, err := getTestPaths()
+	pathInfo, err := getTestPaths(config.filter)
 	if err != nil {
 		if EnableLoger {
 			Logger.Info("DDTest err: " + err.Error())
 		}
-		return ""
+		result.Error = "test_path_discovery_failed"
+		return result
 	}
 	devices := pathInfo.Devices
 	mountPoints := pathInfo.MountPoints
@@ -76,6 +340,7 @@ func DDTestContext(ctx context.Context, language string, enableMultiCheck bool,
 		if enableMultiCheck {
 			targetPath = ""
 			actualTestPaths = mountPoints
+			result.Aliases = pathInfo.Aliases
 		} else {
 			rootPath, tmpPath := getDefaultTestPaths()
 			if runtime.GOOS == "darwin" {
@@ -106,21 +371,33 @@ func DDTestContext(ctx context.Context, language string, enableMultiCheck bool,
 		targetPath = testPath
 		actualTestPaths = []string{testPath}
 	}
-	blockNames := []string{"100MB-4K Block", "1GB-1M Block"}
-	blockCounts := []string{"25600", "1000"}
-	blockSizes := []string{"4k", "1M"}
-	blockFiles := []string{"100MB.test", "1GB.test"}
+	result.Stacks = resolveStacks(actualTestPaths)
+	result.Throttles = detectThrottles(actualTestPaths)
+	specs := config.tests
+	if len(specs) == 0 {
+		specs = defaultDDTests
+	}
+	plans := make([]ddTestPlan, 0, len(specs))
+	for _, spec := range specs {
+		plan, err := newDDTestPlan(spec)
+		if err != nil {
+			loggerInsert(Logger, "DD测试参数无效: "+err.Error())
+			result.Error = "invalid_dd_test"
+			return result
+		}
+		plans = append(plans, plan)
+	}
 	if targetPath != "" {
 		if err := ensurePathExists(targetPath); err != nil {
 			loggerInsert(Logger, "创建目标路径失败: "+targetPath+", 错误: "+err.Error())
 		}
-		blockNames, blockCounts, blockFiles = adjustDDTestSize(targetPath, blockSizes, blockNames, blockCounts, blockFiles)
+		plans = adjustDDTestSize(targetPath, plans, config.pattern)
 	}
-	for ind, bs := range blockSizes {
+	for _, plan := range plans {
 		if ctx.Err() != nil {
 			break
 		}
-		loggerInsert(Logger, "开始测试块大小: "+bs+", 文件: "+blockFiles[ind])
+		loggerInsert(Logger, "开始测试块大小: "+plan.blockSize+", 文件: "+plan.file())
 		if testPath == "" {
 			if enableMultiCheck {
 				loggerInsert(Logger, "开始多路径测试")
@@ -135,15 +412,13 @@ func DDTestContext(ctx context.Context, language string, enableMultiCheck bool,
 						loggerInsert(Logger, "创建路径失败: "+path+", 错误: "+err.Error())
 						continue
 					}
-					adjustedBlockNames, adjustedBlockCounts, adjustedBlockFiles := adjustDDTestSize(path, []string{bs}, []string{blockNames[ind]}, []string{blockCounts[ind]}, []string{blockFiles[ind]})
-					tempResult := ddTest1(ctx, language, path, deviceName, adjustedBlockFiles[0], adjustedBlockNames[0], adjustedBlockCounts[0], bs)
-					actualResults = append(actualResults, tempResult)
+					adjusted := adjustDDTestSize(path, []ddTestPlan{plan}, config.pattern)[0]
+					result.Results = append(result.Results, ddTest1(ctx, config, path, deviceName, adjusted.file(), adjusted.name(), adjusted.count(), adjusted.blockSize))
 				}
 			} else {
 				rootPath, tmpPath := getDefaultTestPaths()
 				loggerInsert(Logger, "开始单路径测试("+rootPath+"或"+tmpPath+")")
-				tempResult := ddTest2(ctx, language, blockFiles[ind], blockNames[ind], blockCounts[ind], bs)
-				actualResults = append(actualResults, tempResult)
+				result.Results = append(result.Results, ddTest2(ctx, config, plan.file(), plan.name(), plan.count(), plan.blockSize))
 				// 检查是否有大于210GB的路径需要额外测试
 				for index, path := range mountPoints {
 					if path == rootPath || path == tmpPath {
@@ -162,13 +437,12 @@ func DDTestContext(ctx context.Context, language string, enableMultiCheck bool,
 							loggerInsert(Logger, "创建大容量路径失败: "+path+", 错误: "+err.Error())
 							continue
 						}
-						adjustedBlockNames, adjustedBlockCounts, adjustedBlockFiles := adjustDDTestSize(path, []string{bs}, []string{blockNames[ind]}, []string{blockCounts[ind]}, []string{blockFiles[ind]})
+						adjusted := adjustDDTestSize(path, []ddTestPlan{plan}, config.pattern)[0]
 						deviceName := path
 						if index < len(devices) {
 							deviceName = devices[index]
 						}
-						tempResult := ddTest1(ctx, language, path, deviceName, adjustedBlockFiles[0], adjustedBlockNames[0], adjustedBlockCounts[0], bs)
-						actualResults = append(actualResults, tempResult)
+						result.Results = append(result.Results, ddTest1(ctx, config, path, deviceName, adjusted.file(), adjusted.name(), adjusted.count(), adjusted.blockSize))
 					}
 				}
 			}
@@ -176,71 +450,51 @@ func DDTestContext(ctx context.Context, language string, enableMultiCheck bool,
 			loggerInsert(Logger, "测试指定路径: "+testPath)
 			if err := ensurePathExists(testPath); err != nil {
 				loggerInsert(Logger, "创建指定路径失败: "+testPath+", 错误: "+err.Error())
-				return localizedText(language, "创建测试路径失败", "Unable to create test path") + "\n"
+				result.Error = "test_path_create_failed"
+				return result
 			}
-			tempResult := ddTest1(ctx, language, testPath, testPath, blockFiles[ind], blockNames[ind], blockCounts[ind], bs)
-			actualResults = append(actualResults, tempResult)
+			result.Results = append(result.Results, ddTest1(ctx, config, testPath, testPath, plan.file(), plan.name(), plan.count(), plan.blockSize))
 		}
 	}
-	return renderLegacyResults(language, actualResults, generateDDTestHeader)
+	return result
 }
 
-// adjustDDTestSize 根据可用磁盘空间调整DD测试参数
-func adjustDDTestSize(testPath string, blockSizes, blockNames, blockCounts, blockFiles []string) ([]string, []string, []string) {
-	adjustedBlockNames := make([]string, len(blockNames))
-	adjustedBlockCounts := make([]string, len(blockCounts))
-	adjustedBlockFiles := make([]string, len(blockFiles))
-	copy(adjustedBlockNames, blockNames)
-	copy(adjustedBlockCounts, blockCounts)
-	copy(adjustedBlockFiles, blockFiles)
+// adjustDDTestSize 根据可用磁盘空间调整DD测试参数，空间不足时缩小为可用空间的1/5（至少20MB，至多原大小的一半）；
+// 数据源文件与测试文件在同一目录时，所需空间与缩小后的大小都按两倍计算
+func adjustDDTestSize(testPath string, plans []ddTestPlan, pattern DataPattern) []ddTestPlan {
+	adjustedPlans := make([]ddTestPlan, len(plans))
+	copy(adjustedPlans, plans)
 	usage, err := disk.Usage(testPath)
 	if err != nil {
 		loggerInsert(Logger, "获取磁盘使用情况失败: "+err.Error()+", 使用默认测试参数")
-		return blockNames, blockCounts, blockFiles
+		return adjustedPlans
 	}
 	availableBytes := usage.Free
-	for i, bs := range blockSizes {
-		var requiredBytes uint64
-		if bs == "4k" {
-			requiredBytes = 100 * 1024 * 1024
-		} else { // bs == "1M"
-			requiredBytes = 1024 * 1024 * 1024
-		}
-		if availableBytes < requiredBytes*3/2 {
-			testSizeBytes := availableBytes / 5
+	copies := uint64(1)
+	if ddSourceOnDisk(pattern) {
+		copies = 2
+	}
+	for i, plan := range plans {
+		requiredBytes := plan.totalBytes
+		if availableBytes < requiredBytes*copies*3/2 {
+			testSizeBytes := availableBytes / (5 * copies)
 			minSizeBytes := uint64(20 * 1024 * 1024)
 			if testSizeBytes < minSizeBytes {
 				testSizeBytes = minSizeBytes
 			}
-			if bs == "4k" {
-				sizeMB := int(testSizeBytes / (1024 * 1024))
-				if sizeMB > 50 {
-					sizeMB = 50
-				}
-				adjustedBlockFiles[i] = fmt.Sprintf("%dMB.test", sizeMB)
-				adjustedBlockNames[i] = fmt.Sprintf("%dMB-4K Block", sizeMB)
-				adjustedBlockCounts[i] = fmt.Sprintf("%d", sizeMB*256)
-				loggerInsert(Logger, fmt.Sprintf("调整4K块测试大小为%dMB, 块数%s", sizeMB, adjustedBlockCounts[i]))
-			} else {
-				sizeMB := int(testSizeBytes / (1024 * 1024))
-				if sizeMB > 500 {
-					sizeMB = 500
-				}
-				if sizeMB >= 1024 {
-					adjustedBlockFiles[i] = fmt.Sprintf("%dGB.test", sizeMB/1024)
-					adjustedBlockNames[i] = fmt.Sprintf("%dGB-1M Block", sizeMB/1024)
-				} else {
-					adjustedBlockFiles[i] = fmt.Sprintf("%dMB.test", sizeMB)
-					adjustedBlockNames[i] = fmt.Sprintf("%dMB-1M Block", sizeMB)
-				}
-				adjustedBlockCounts[i] = fmt.Sprintf("%d", sizeMB)
-				loggerInsert(Logger, fmt.Sprintf("调整1M块测试大小为%dMB, 块数%s", sizeMB, adjustedBlockCounts[i]))
+			if testSizeBytes > requiredBytes/2 {
+				testSizeBytes = requiredBytes / 2
 			}
+			testSizeBytes = testSizeBytes / (1024 * 1024) * (1024 * 1024)
+			// 保持为块大小的整数倍，count才能写满报告的大小
+			testSizeBytes = max(testSizeBytes-testSizeBytes%plan.blockBytes, plan.blockBytes)
+			adjustedPlans[i].totalBytes = testSizeBytes
+			loggerInsert(Logger, fmt.Sprintf("调整%s块测试大小为%s, 块数%s", plan.blockSize, adjustedPlans[i].sizeLabel(), adjustedPlans[i].count()))
 		} else {
 			loggerInsert(Logger, fmt.Sprintf("可用空间充足(%d字节)，使用默认测试参数", availableBytes))
 		}
 	}
-	return adjustedBlockNames, adjustedBlockCounts, adjustedBlockFiles
+	return adjustedPlans
 }
 
 // getDevNullPath 获取系统对应的null设备路径
@@ -265,6 +519,41 @@ func execDDTest(ifKey, ofKey, bs, blockCount string) (string, error) {
 }
 
 func execDDTestContext(ctx context.Context, ifKey, ofKey, bs, blockCount string) (string, error) {
+	return execDDCommandContext(ctx, ifKey, ofKey, bs, blockCount, ddWriteFlags())
+}
+
+// ddWriteFlags Windows与macOS的dd不支持oflag=direct
+func ddWriteFlags() []string {
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		return []string{"oflag=direct"}
+	}
+	return nil
+}
+
+// readDDTestFile 优先以iflag=direct读取测试文件，不支持时先清除该文件的页缓存再普通读取，
+// 返回读取方式：direct、cache-dropped或possibly-cached
+func readDDTestFile(ctx context.Context, config ddLegacyConfig, blockFile, ofKey, bs, blockCount string) (string, string, error) {
+	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+		tempText, err := execDDCommandContext(ctx, blockFile, ofKey, bs, blockCount, []string{"iflag=direct"})
+		if err == nil {
+			if _, ok := parseDDMeasurement(tempText, blockCount); ok {
+				return tempText, "direct", nil
+			}
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return tempText, "", ctxErr
+		}
+		loggerInsert(Logger, "iflag=direct读取不可用，改为清除页缓存后读取: "+tempText)
+	}
+	readMode := "possibly-cached"
+	if dropPageCache(blockFile, config.dropCaches) {
+		readMode = "cache-dropped"
+	}
+	tempText, err := execDDTestContext(ctx, blockFile, ofKey, bs, blockCount)
+	return tempText, readMode, err
+}
+
+func execDDCommandContext(ctx context.Context, ifKey, ofKey, bs, blockCount string, flags []string) (string, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -288,12 +577,12 @@ func execDDTestContext(ctx context.Context, ifKey, ofKey, bs, blockCount string)
 	}
 	loggerInsert(Logger, fmt.Sprintf("执行DD命令: %s, if=%s, of=%s, bs=%s, count=%s", ddCmd, ifKey, ofKey, bs, blockCount))
 	parts := strings.Split(ddCmd, " ")
-	args := append(parts[1:], "if="+ifKey, "of="+ofKey, "bs="+bs, "count="+blockCount)
-	if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
-		args = append(args, "oflag=direct")
-	}
+	args := append(parts[1:], ddArgs(ifKey, ofKey, bs, blockCount, flags)...)
 	loggerInsert(Logger, fmt.Sprintf("完整命令参数: %s %s", parts[0], strings.Join(args, " ")))
-	cmd2 := exec.CommandContext(ctx, parts[0], args...)
+	if ofKey != getDevNullPath() {
+		markArtifact(ofKey)
+	}
+	cmd2 := benchmarkCommand(ctx, parts[0], args...)
 	outputBytes, err := cmd2.CombinedOutput()
 	if err != nil {
 		loggerInsert(Logger, "DD命令执行失败: "+err.Error())
@@ -320,48 +609,81 @@ func execDDTestContext(ctx context.Context, ifKey, ofKey, bs, blockCount string)
 	return tempText, nil
 }
 
+// ddArgs 生成dd的if/of/bs/count参数及附加标志
+func ddArgs(ifKey, ofKey, bs, blockCount string, flags []string) []string {
+	return append([]string{"if=" + ifKey, "of=" + ofKey, "bs=" + bs, "count=" + blockCount}, flags...)
+}
+
+// recordDDWrite 将写入测试的dd输出记录到结果中
+func recordDDWrite(result *DDResult, tempText, blockCount string, err error) {
+	if err != nil {
+		result.WriteStatus, result.FailureReason = "failed", "write_failed"
+		return
+	}
+	measurement, ok := parseDDMeasurement(tempText, blockCount)
+	loggerInsert(Logger, "写入测试结果解析: "+renderDDMeasurement(measurement, ok, DefaultUnitFormat()))
+	if !ok {
+		result.WriteStatus, result.FailureReason = "unparsable", "write_unparsable"
+		return
+	}
+	result.WriteStatus, result.Write = "ok", measurement
+}
+
+// recordDDRead 将读取测试的dd输出记录到结果中
+func recordDDRead(result *DDResult, tempText, blockCount string, err error) {
+	if err != nil {
+		result.ReadStatus = "failed"
+		if result.FailureReason == "" {
+			result.FailureReason = "read_failed"
+		}
+		return
+	}
+	measurement, ok := parseDDMeasurement(tempText, blockCount)
+	loggerInsert(Logger, "读取测试结果解析: "+renderDDMeasurement(measurement, ok, DefaultUnitFormat()))
+	if !ok {
+		result.ReadStatus = "unparsable"
+		if result.FailureReason == "" {
+			result.FailureReason = "read_unparsable"
+		}
+		return
+	}
+	result.ReadStatus, result.Read = "ok", measurement
+}
+
+// markDDReadCanceled 记录读取阶段被取消
+func markDDReadCanceled(result *DDResult) {
+	result.ReadStatus = "canceled"
+	if result.FailureReason == "" {
+		result.FailureReason = "canceled"
+	}
+}
+
 // ddTest1 无重试机制
-func ddTest1(ctx context.Context, language, path, deviceName, blockFile, blockName, blockCount, bs string) string {
-	var result string
+func ddTest1(ctx context.Context, config ddLegacyConfig, path, deviceName, blockFile, blockName, blockCount, bs string) DDResult {
+	result := DDResult{Path: path, Device: strings.TrimSpace(deviceName), BlockSize: bs, BlockName: blockName}
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
 	}
 	fullBlockFile := filepath.Join(path, blockFile)
 	// 写入测试
-	var writeSource string
-	if runtime.GOOS == "windows" {
-		zeroFile := filepath.Join(path, "zero_temp")
-		defer os.Remove(zeroFile)
-		if err := createZeroFile(zeroFile, bs, blockCount); err != nil {
-			loggerInsert(Logger, "创建零文件失败: "+err.Error())
-			return ""
-		}
-		writeSource = zeroFile
-	} else {
-		writeSource = getDevZeroPath()
+	result.DataPattern = config.writePattern().String()
+	writeSource, cleanupSource, err := prepareDDWriteSource(path, bs, blockCount, config.pattern)
+	defer cleanupSource()
+	if err != nil {
+		loggerInsert(Logger, "创建写入数据源失败: "+err.Error())
+		result.FailureReason = "write_source_unavailable"
+		return result
 	}
 	tempText, err := execDDTestContext(ctx, writeSource, fullBlockFile, bs, blockCount)
-	defer os.Remove(fullBlockFile)
-	deviceWidth := getMountPointColumnWidth(strings.TrimSpace(deviceName))
-	if deviceWidth < 15 {
-		deviceWidth = 15
-	}
-	result += fmt.Sprintf("%-*s    %-15s    ", deviceWidth, strings.TrimSpace(deviceName), blockName)
+	defer removeArtifact(fullBlockFile)
 	if err != nil {
 		loggerInsert(Logger, "Write test error: "+err.Error())
-		result += fmt.Sprintf("%-30s    ", localizedText(language, "写入失败", "Write failed"))
-	} else {
-		parsedResult := parseResultDD(tempText, blockCount)
-		loggerInsert(Logger, "写入测试结果解析: "+parsedResult)
-		if strings.TrimSpace(parsedResult) == "" {
-			parsedResult = localizedText(language, "无法解析结果", "Unable to parse result")
-		}
-		result += fmt.Sprintf("%-30s    ", parsedResult)
-		if !sleepContext(ctx, time.Second) {
-			result += fmt.Sprintf("%-30s\n", localizedText(language, "读取已取消", "Read canceled"))
-			return result
-		}
+	}
+	recordDDWrite(&result, tempText, blockCount, err)
+	if err == nil && !sleepContext(ctx, time.Second) {
+		markDDReadCanceled(&result)
+		return result
 	}
 	// 同步
 	syncCmd := exec.Command("sync")
@@ -371,8 +693,9 @@ func ddTest1(ctx context.Context, language, path, deviceName, blockFile, blockNa
 	}
 	// 读取测试
 	devNull := getDevNullPath()
-	tempText, err = execDDTestContext(ctx, fullBlockFile, devNull, bs, blockCount)
-	defer os.Remove(fullBlockFile)
+	var readMode string
+	tempText, readMode, err = readDDTestFile(ctx, config, fullBlockFile, devNull, bs, blockCount)
+	defer removeArtifact(fullBlockFile)
 	if err != nil {
 		loggerInsert(Logger, "Read test error: "+err.Error())
 	}
@@ -383,83 +706,66 @@ func ddTest1(ctx context.Context, language, path, deviceName, blockFile, blockNa
 			loggerInsert(Logger, "Read test (first attempt) output: "+tempText)
 		}
 		if !sleepContext(ctx, time.Second) {
-			result += fmt.Sprintf("%-30s\n", localizedText(language, "读取已取消", "Read canceled"))
+			markDDReadCanceled(&result)
 			return result
 		}
 		readTestFile := filepath.Join(path, "read_"+blockFile)
 		tempText, err = execDDTestContext(ctx, fullBlockFile, readTestFile, bs, blockCount)
-		defer os.Remove(readTestFile)
+		defer removeArtifact(readTestFile)
 		if err != nil {
 			loggerInsert(Logger, "Read test (second attempt) error: "+err.Error())
 		}
 	}
-	if err != nil {
-		result += fmt.Sprintf("%-30s", localizedText(language, "读取失败", "Read failed"))
-	} else {
-		parsedResult := parseResultDD(tempText, blockCount)
-		loggerInsert(Logger, "读取测试结果解析: "+parsedResult)
-		if strings.TrimSpace(parsedResult) == "" {
-			parsedResult = localizedText(language, "无法解析结果", "Unable to parse result")
-		}
-		result += fmt.Sprintf("%-30s", parsedResult)
+	recordDDRead(&result, tempText, blockCount, err)
+	if result.ReadStatus == "ok" {
+		result.ReadMode = readMode
 	}
-	result += "\n"
 	return result
 }
 
 // ddTest2 有重试机制，重试至临时目录
-func ddTest2(ctx context.Context, language, blockFile, blockName, blockCount, bs string) string {
-	var result string
+func ddTest2(ctx context.Context, config ddLegacyConfig, blockFile, blockName, blockCount, bs string) DDResult {
+	result := DDResult{BlockSize: bs, BlockName: blockName}
 	var testFilePath string
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
 	}
 	rootPath, tmpPath := getDefaultTestPaths()
+	result.DataPattern = config.writePattern().String()
 	if runtime.GOOS == "darwin" {
 		testFilePath = tmpPath
-		deviceWidth := getMountPointColumnWidth(tmpPath)
-		if deviceWidth < 15 {
-			deviceWidth = 15
-		}
-		result += fmt.Sprintf("%-*s    %-15s    ", deviceWidth, tmpPath, blockName)
 		fullBlockFile := filepath.Join(tmpPath, blockFile)
-		writeSource := getDevZeroPath()
+		writeSource, cleanupSource, err := prepareDDWriteSource(tmpPath, bs, blockCount, config.pattern)
+		defer cleanupSource()
+		if err != nil {
+			loggerInsert(Logger, "创建写入数据源失败: "+err.Error())
+			result.FailureReason = "write_source_unavailable"
+			return result
+		}
 		tempText, err := execDDTestContext(ctx, writeSource, fullBlockFile, bs, blockCount)
-		defer os.Remove(fullBlockFile)
+		defer removeArtifact(fullBlockFile)
 		if err != nil {
 			loggerInsert(Logger, "execDDTest error for "+tmpPath+" path: "+err.Error())
-			result += fmt.Sprintf("%-30s    ", localizedText(language, "写入失败", "Write failed"))
-		} else {
-			parsedResult := parseResultDD(tempText, blockCount)
-			loggerInsert(Logger, "写入测试路径: "+testFilePath)
-			loggerInsert(Logger, "写入测试结果解析: "+parsedResult)
-			if strings.TrimSpace(parsedResult) == "" {
-				parsedResult = localizedText(language, "无法解析结果", "Unable to parse result")
-			}
-			result += fmt.Sprintf("%-30s    ", parsedResult)
 		}
+		loggerInsert(Logger, "写入测试路径: "+testFilePath)
+		recordDDWrite(&result, tempText, blockCount, err)
 	} else {
-		var writeSource string
-		if runtime.GOOS == "windows" {
-			zeroFile := filepath.Join(rootPath, "zero_temp")
-			defer os.Remove(zeroFile)
-			if err := createZeroFile(zeroFile, bs, blockCount); err != nil {
-				loggerInsert(Logger, "创建零文件失败: "+err.Error())
-				zeroFile = filepath.Join(tmpPath, "zero_temp")
-				defer os.Remove(zeroFile)
-				if err := createZeroFile(zeroFile, bs, blockCount); err != nil {
-					loggerInsert(Logger, "在临时目录创建零文件失败: "+err.Error())
-					return ""
-				}
+		writeSource, cleanupSource, err := prepareDDWriteSource(rootPath, bs, blockCount, config.pattern)
+		defer cleanupSource()
+		if err != nil {
+			loggerInsert(Logger, "创建写入数据源失败: "+err.Error())
+			writeSource, cleanupSource, err = prepareDDWriteSource(tmpPath, bs, blockCount, config.pattern)
+			defer cleanupSource()
+			if err != nil {
+				loggerInsert(Logger, "在临时目录创建写入数据源失败: "+err.Error())
+				result.FailureReason = "write_source_unavailable"
+				return result
 			}
-			writeSource = zeroFile
-		} else {
-			writeSource = getDevZeroPath()
 		}
 		fullBlockFile := filepath.Join(rootPath, blockFile)
 		tempText, err := execDDTestContext(ctx, writeSource, fullBlockFile, bs, blockCount)
-		defer os.Remove(fullBlockFile)
+		defer removeArtifact(fullBlockFile)
 		if err != nil {
 			loggerInsert(Logger, "execDDTest error for "+rootPath+" path: "+err.Error())
 		}
@@ -467,49 +773,32 @@ func ddTest2(ctx context.Context, language, blockFile, blockName, blockCount, bs
 			strings.Contains(tempText, "失败") || strings.Contains(tempText, "无效的参数") || err != nil {
 			loggerInsert(Logger, "写入测试到"+rootPath+"失败，尝试写入到"+tmpPath+": "+tempText)
 			if !sleepContext(ctx, time.Second) {
+				result.FailureReason = "canceled"
 				return result
 			}
-			if runtime.GOOS == "windows" {
-				zeroFile := filepath.Join(tmpPath, "zero_temp")
-				defer os.Remove(zeroFile)
-				if err := createZeroFile(zeroFile, bs, blockCount); err != nil {
-					loggerInsert(Logger, "在临时目录创建零文件失败: "+err.Error())
-					return ""
+			if writeSource != getDevZeroPath() {
+				writeSource, cleanupSource, err = prepareDDWriteSource(tmpPath, bs, blockCount, config.pattern)
+				defer cleanupSource()
+				if err != nil {
+					loggerInsert(Logger, "在临时目录创建写入数据源失败: "+err.Error())
+					result.FailureReason = "write_source_unavailable"
+					return result
 				}
-				writeSource = zeroFile
 			}
 			fullBlockFile = filepath.Join(tmpPath, blockFile)
 			tempText, err = execDDTestContext(ctx, writeSource, fullBlockFile, bs, blockCount)
-			defer os.Remove(fullBlockFile)
+			defer removeArtifact(fullBlockFile)
 			if err != nil {
 				loggerInsert(Logger, "execDDTest error for "+tmpPath+" path: "+err.Error())
 			}
 			testFilePath = tmpPath
-			deviceWidth := getMountPointColumnWidth(tmpPath)
-			if deviceWidth < 14 {
-				deviceWidth = 14
-			}
-			result += fmt.Sprintf("%-*s    %-15s    ", deviceWidth, tmpPath, blockName)
 		} else {
 			testFilePath = rootPath
-			deviceWidth := getMountPointColumnWidth(rootPath)
-			if deviceWidth < 14 {
-				deviceWidth = 14
-			}
-			result += fmt.Sprintf("%-*s    %-15s    ", deviceWidth, rootPath, blockName)
-		}
-		if err != nil {
-			result += fmt.Sprintf("%-30s    ", localizedText(language, "写入失败", "Write failed"))
-		} else {
-			parsedResult := parseResultDD(tempText, blockCount)
-			loggerInsert(Logger, "写入测试路径: "+testFilePath)
-			loggerInsert(Logger, "写入测试结果解析: "+parsedResult)
-			if strings.TrimSpace(parsedResult) == "" {
-				parsedResult = localizedText(language, "无法解析结果", "Unable to parse result")
-			}
-			result += fmt.Sprintf("%-30s    ", parsedResult)
 		}
+		loggerInsert(Logger, "写入测试路径: "+testFilePath)
+		recordDDWrite(&result, tempText, blockCount, err)
 	}
+	result.Path, result.Device = testFilePath, testFilePath
 	if runtime.GOOS != "windows" {
 		syncCmd := exec.Command("sync")
 		err := syncCmd.Run()
@@ -518,14 +807,14 @@ func ddTest2(ctx context.Context, language, blockFile, blockName, blockCount, bs
 		}
 	}
 	if !sleepContext(ctx, time.Second) {
-		result += fmt.Sprintf("%-30s\n", localizedText(language, "读取已取消", "Read canceled"))
+		markDDReadCanceled(&result)
 		return result
 	}
 	// 读取测试
 	fullBlockFile := filepath.Join(testFilePath, blockFile)
 	devNull := getDevNullPath()
-	tempText, err := execDDTestContext(ctx, fullBlockFile, devNull, bs, blockCount)
-	defer os.Remove(fullBlockFile)
+	tempText, readMode, err := readDDTestFile(ctx, config, fullBlockFile, devNull, bs, blockCount)
+	defer removeArtifact(fullBlockFile)
 	if err != nil {
 		loggerInsert(Logger, "execDDTest read error for "+testFilePath+" path: "+err.Error())
 	}
@@ -533,12 +822,12 @@ func ddTest2(ctx context.Context, language, blockFile, blockName, blockCount, bs
 		strings.Contains(tempText, "失败") || strings.Contains(tempText, "无效的参数") {
 		loggerInsert(Logger, "读取测试到null设备失败，尝试读取到临时文件: "+tempText)
 		if !sleepContext(ctx, time.Second) {
-			result += fmt.Sprintf("%-30s\n", localizedText(language, "读取已取消", "Read canceled"))
+			markDDReadCanceled(&result)
 			return result
 		}
 		readFile := filepath.Join(tmpPath, "read_"+blockFile)
 		tempText, err = execDDTestContext(ctx, fullBlockFile, readFile, bs, blockCount)
-		defer os.Remove(readFile)
+		defer removeArtifact(readFile)
 		if err != nil {
 			loggerInsert(Logger, "execDDTest read error for tmp path: "+err.Error())
 		}
@@ -546,77 +835,83 @@ func ddTest2(ctx context.Context, language, blockFile, blockName, blockCount, bs
 			strings.Contains(tempText, "失败") || strings.Contains(tempText, "无效的参数") {
 			loggerInsert(Logger, "读取测试到临时文件失败，尝试读取到当前目录: "+tempText)
 			if !sleepContext(ctx, time.Second) {
-				result += fmt.Sprintf("%-30s\n", localizedText(language, "读取已取消", "Read canceled"))
+				markDDReadCanceled(&result)
 				return result
 			}
 			readFile = filepath.Join(testFilePath, "read_"+blockFile)
 			tempText, err = execDDTestContext(ctx, fullBlockFile, readFile, bs, blockCount)
-			defer os.Remove(readFile)
+			defer removeArtifact(readFile)
 			if err != nil {
 				loggerInsert(Logger, "execDDTest read error for current path: "+err.Error())
 			}
 		}
 	}
-	if err != nil {
-		result += fmt.Sprintf("%-30s", localizedText(language, "读取失败", "Read failed"))
-	} else {
-		parsedResult := parseResultDD(tempText, blockCount)
-		loggerInsert(Logger, "读取测试结果解析: "+parsedResult)
-		if strings.TrimSpace(parsedResult) == "" {
-			parsedResult = localizedText(language, "无法解析结果", "Unable to parse result")
-		}
-		result += fmt.Sprintf("%-30s", parsedResult)
+	recordDDRead(&result, tempText, blockCount, err)
+	if result.ReadStatus == "ok" {
+		result.ReadMode = readMode
 	}
-	result += "\n"
 	return result
 }
 
-// createZeroFile 为Windows系统创建指定大小的零文件
-func createZeroFile(filePath, bs, blockCount string) error {
-	var blockSize int64
-	if strings.HasSuffix(strings.ToLower(bs), "k") {
-		size := strings.TrimSuffix(strings.ToLower(bs), "k")
-		if s, err := strconv.ParseInt(size, 10, 64); err == nil {
-			blockSize = s * 1024
-		} else {
-			return fmt.Errorf("invalid block size: %s", bs)
-		}
-	} else if strings.HasSuffix(strings.ToUpper(bs), "M") {
-		size := strings.TrimSuffix(strings.ToUpper(bs), "M")
-		if s, err := strconv.ParseInt(size, 10, 64); err == nil {
-			blockSize = s * 1024 * 1024
-		} else {
-			return fmt.Errorf("invalid block size: %s", bs)
-		}
-	} else {
-		if s, err := strconv.ParseInt(bs, 10, 64); err == nil {
-			blockSize = s
-		} else {
-			return fmt.Errorf("invalid block size: %s", bs)
-		}
+// prepareDDWriteSource 准备dd写入测试的数据源：非Windows下零数据直接使用/dev/zero，
+// 其余情况在dir中生成对应数据模式的源文件，返回的cleanup负责删除
+func prepareDDWriteSource(dir, bs, blockCount string, pattern DataPattern) (string, func(), error) {
+	if !ddSourceOnDisk(pattern) {
+		return getDevZeroPath(), func() {}, nil
+	}
+	name := ddSourceName(pattern)
+	sourceFile := filepath.Join(dir, name)
+	if err := createPatternFile(sourceFile, bs, blockCount, pattern); err != nil {
+		removeArtifact(sourceFile)
+		return "", func() {}, err
+	}
+	return sourceFile, func() { removeArtifact(sourceFile) }, nil
+}
+
+// ddSourceOnDisk 写入测试的数据源是否需要在测试目录中生成与测试文件同样大小的文件
+func ddSourceOnDisk(pattern DataPattern) bool {
+	return runtime.GOOS == "windows" || (pattern.Kind != "" && pattern.Kind != "zeros")
+}
+
+// ddSourceName 测试目录中数据源文件的名称
+func ddSourceName(pattern DataPattern) string {
+	if pattern.Kind == "" || pattern.Kind == "zeros" {
+		return "zero_temp"
 	}
-	count, err := strconv.ParseInt(blockCount, 10, 64)
+	return "pattern_temp"
+}
+
+// createPatternFile 创建指定大小、按数据模式填充的源文件
+func createPatternFile(filePath, bs, blockCount string, pattern DataPattern) error {
+	blockSize, err := parseSizeBytes(bs)
+	if err != nil {
+		return fmt.Errorf("invalid block size: %s", bs)
+	}
+	count, err := strconv.ParseUint(blockCount, 10, 64)
 	if err != nil {
 		return fmt.Errorf("invalid block count: %s", blockCount)
 	}
 	totalSize := blockSize * count
+	markArtifact(filePath)
 	file, err := os.Create(filePath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
-	buffer := make([]byte, 8192) // 8KB 缓冲区
-	written := int64(0)
+	buffer := make([]byte, 64*1024) // 64KB 缓冲区，每次写入前重新填充以避免重复数据
+	written := uint64(0)
 	for written < totalSize {
-		writeSize := int64(len(buffer))
-		if totalSize-written < writeSize {
-			writeSize = totalSize - written
+		writeSize := min(uint64(len(buffer)), totalSize-written)
+		if written == 0 || pattern.Kind == "random" || pattern.Kind == "compressible" {
+			if err := fillPatternBlock(buffer[:writeSize], pattern); err != nil {
+				return err
+			}
 		}
 		n, err := file.Write(buffer[:writeSize])
 		if err != nil {
 			return err
 		}
-		written += int64(n)
+		written += uint64(n)
 	}
 	return nil
 }
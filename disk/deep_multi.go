@@ -12,13 +12,15 @@ type MultiPathResult struct {
 	Status        string         `json:"status"`
 	Paths         []MatrixResult `json:"paths"`
 	Error         string         `json:"error,omitempty"`
+	// Units is the unit system of the human-readable output.
+	Units UnitFormat `json:"units"`
 }
 
 func RunDeepMultiPathMatrix(ctx context.Context, paths []string, config MatrixConfig) MultiPathResult {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	result := MultiPathResult{SchemaVersion: "goecs.disk/deep-multi-v1", Status: "skipped", Paths: []MatrixResult{}}
+	result := MultiPathResult{SchemaVersion: "goecs.disk/deep-multi-v1", Status: "skipped", Paths: []MatrixResult{}, Units: config.Units.resolved()}
 	seen := make(map[string]struct{})
 	for _, path := range paths {
 		absolute, err := filepath.Abs(strings.TrimSpace(path))
@@ -42,16 +44,22 @@ func RunDeepMultiPathMatrix(ctx context.Context, paths []string, config MatrixCo
 		result.Error = "no explicit deep disk paths configured"
 		return result
 	}
-	ok := 0
+	// 演练只生成计划，planned路径不计为成功
+	ok, planned := 0, 0
 	for _, pathResult := range result.Paths {
-		if pathResult.Status == "ok" {
+		switch pathResult.Status {
+		case "ok":
 			ok++
+		case "planned":
+			planned++
 		}
 	}
 	if ok == len(result.Paths) {
 		result.Status = "ok"
 	} else if ok > 0 {
 		result.Status = "partial"
+	} else if planned == len(result.Paths) {
+		result.Status = "planned"
 	} else {
 		result.Status = "unavailable"
 	}
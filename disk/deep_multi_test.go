@@ -19,3 +19,11 @@ func TestRunDeepMultiPathMatrixRejectsUnsafeSizePerPath(t *testing.T) {
 		t.Fatalf("unexpected multi-path safety result: %+v", result)
 	}
 }
+
+func TestRunDeepMultiPathMatrixDryRunIsPlanned(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	result := RunDeepMultiPathMatrix(context.Background(), []string{first, second}, MatrixConfig{SizeBytes: 16 << 20, DryRun: true})
+	if result.Status != "planned" || len(result.Paths) != 2 || result.Paths[0].Status != "planned" {
+		t.Fatalf("dry run counted as a benchmark: %+v", result)
+	}
+}
@@ -0,0 +1,214 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// sysfsRoot 测试时可替换为伪造的sysfs目录
+var sysfsRoot = "/sys"
+
+// maxStackDepth 限制设备栈的遍历深度，防止异常的sysfs链接造成死循环
+const maxStackDepth = 8
+
+// mountDevice 返回挂载点文件系统的设备号(maj:min)与所属整盘名称
+func mountDevice(mountPoint, source string) (deviceID, parentDisk string) {
+	deviceID, name := mountBlockDevice(mountPoint, source)
+	if name != "" {
+		parentDisk = wholeDisk(name)
+	}
+	return deviceID, parentDisk
+}
+
+// mountBlockDevice 返回挂载点的设备号以及承载它的块设备名称
+// btrfs子卷等使用匿名设备号，此时改用挂载源设备节点
+func mountBlockDevice(mountPoint, source string) (deviceID, name string) {
+	var st unix.Stat_t
+	if err := unix.Stat(mountPoint, &st); err == nil {
+		deviceID = formatDeviceNumber(uint64(st.Dev))
+		name = sysfsBlockName(deviceID)
+	}
+	if name == "" && strings.HasPrefix(source, "/dev/") {
+		if err := unix.Stat(source, &st); err == nil && st.Mode&unix.S_IFMT == unix.S_IFBLK {
+			name = sysfsBlockName(formatDeviceNumber(uint64(st.Rdev)))
+		}
+	}
+	return deviceID, name
+}
+
+func formatDeviceNumber(dev uint64) string {
+	return fmt.Sprintf("%d:%d", unix.Major(dev), unix.Minor(dev))
+}
+
+// sysfsBlockName 通过 /sys/dev/block 将设备号解析为块设备名称
+func sysfsBlockName(deviceID string) string {
+	target, err := filepath.EvalSymlinks(filepath.Join(sysfsRoot, "dev", "block", deviceID))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
+
+// wholeDisk 分区返回所属整盘名称，其他设备原样返回
+func wholeDisk(name string) string {
+	target, err := filepath.EvalSymlinks(filepath.Join(sysfsRoot, "class", "block", name))
+	if err != nil {
+		return name
+	}
+	if _, err := os.Stat(filepath.Join(target, "partition")); err == nil {
+		return filepath.Base(filepath.Dir(target))
+	}
+	return name
+}
+
+// storageStack 解析挂载点下方的块设备栈
+func storageStack(mountPoint, source string) StorageStack {
+	_, name := mountBlockDevice(mountPoint, source)
+	if name == "" {
+		return StorageStack{}
+	}
+	return StorageStack{Layers: resolveLayers(name)}
+}
+
+// resolveLayers 沿 slaves 逐层向下遍历，分区折叠到整盘，直到物理磁盘
+func resolveLayers(name string) []StorageLayer {
+	var layers []StorageLayer
+	level := []string{name}
+	for depth := 0; depth < maxStackDepth && len(level) > 0; depth++ {
+		var devices, next []string
+		for _, device := range level {
+			if device = wholeDisk(device); !slices.Contains(devices, device) {
+				devices = append(devices, device)
+			}
+		}
+		virtual := true
+		for _, device := range devices {
+			slaves := sysfsLinks(device, "slaves")
+			if len(slaves) == 0 {
+				virtual = false
+			}
+			next = append(next, slaves...)
+		}
+		if !virtual {
+			layers = append(layers, StorageLayer{Kind: diskKinds(devices), Devices: devices})
+			break
+		}
+		kind, raidLevel := virtualKind(devices[0])
+		layers = append(layers, StorageLayer{Kind: kind, Level: raidLevel, Devices: devices})
+		level = next
+	}
+	return layers
+}
+
+// sysfsLinks 列出块设备的 slaves 或 holders
+func sysfsLinks(name, relation string) []string {
+	entries, err := os.ReadDir(filepath.Join(sysfsRoot, "class", "block", name, relation))
+	if err != nil {
+		return nil
+	}
+	links := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		links = append(links, entry.Name())
+	}
+	return links
+}
+
+// virtualKind 识别device-mapper与md设备的类型
+func virtualKind(name string) (kind, level string) {
+	base := filepath.Join(sysfsRoot, "class", "block", name)
+	if uuid, err := os.ReadFile(filepath.Join(base, "dm", "uuid")); err == nil {
+		switch value := strings.TrimSpace(string(uuid)); {
+		case strings.HasPrefix(value, "CRYPT-"):
+			return "dm-crypt", ""
+		case strings.HasPrefix(value, "LVM-"):
+			return "lvm", ""
+		case strings.HasPrefix(value, "mpath-"):
+			return "multipath", ""
+		}
+		return "dm", ""
+	}
+	if raid, err := os.ReadFile(filepath.Join(base, "md", "level")); err == nil {
+		return "md", strings.TrimSpace(string(raid))
+	}
+	return "virtual", ""
+}
+
+// diskKinds 返回物理磁盘类型，类型不同时以+连接
+func diskKinds(devices []string) string {
+	var kinds []string
+	for _, device := range devices {
+		if kind := diskKind(device); !slices.Contains(kinds, kind) {
+			kinds = append(kinds, kind)
+		}
+	}
+	return strings.Join(kinds, "+")
+}
+
+func diskKind(name string) string {
+	switch {
+	case strings.HasPrefix(name, "nvme"):
+		return "nvme"
+	case strings.HasPrefix(name, "mmcblk"):
+		return "mmc"
+	case strings.HasPrefix(name, "vd"):
+		return "virtio"
+	case strings.HasPrefix(name, "xvd"):
+		return "xen"
+	case strings.HasPrefix(name, "loop"):
+		return "loop"
+	}
+	rotational, err := os.ReadFile(filepath.Join(sysfsRoot, "class", "block", name, "queue", "rotational"))
+	if err != nil {
+		return "disk"
+	}
+	if strings.TrimSpace(string(rotational)) == "1" {
+		return "hdd"
+	}
+	return "ssd"
+}
+
+// deviceHolders 返回持有该块设备(或其分区)的最上层设备，如md阵列或dm映射
+func deviceHolders(path string) []string {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil || st.Mode&unix.S_IFMT != unix.S_IFBLK {
+		return nil
+	}
+	name := sysfsBlockName(formatDeviceNumber(uint64(st.Rdev)))
+	if name == "" {
+		return nil
+	}
+	level := append(sysfsLinks(name, "holders"), partitionHolders(name)...)
+	var top []string
+	for depth := 0; depth < maxStackDepth && len(level) > 0; depth++ {
+		var next []string
+		for _, holder := range level {
+			if above := sysfsLinks(holder, "holders"); len(above) > 0 {
+				next = append(next, above...)
+			} else if !slices.Contains(top, holder) {
+				top = append(top, holder)
+			}
+		}
+		level = next
+	}
+	return top
+}
+
+// partitionHolders 收集整盘各分区的持有者
+func partitionHolders(name string) []string {
+	entries, err := os.ReadDir(filepath.Join(sysfsRoot, "class", "block", name))
+	if err != nil {
+		return nil
+	}
+	var holders []string
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), name) {
+			holders = append(holders, sysfsLinks(entry.Name(), "holders")...)
+		}
+	}
+	return holders
+}
@@ -0,0 +1,85 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeSysfs 创建伪造的sysfs目录，devices 为设备名到 devices 下相对路径的映射
+func fakeSysfs(t *testing.T, devices map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{filepath.Join(root, "dev", "block"), filepath.Join(root, "class", "block")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, relative := range devices {
+		target := filepath.Join(root, "devices", relative)
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, filepath.Join(root, "class", "block", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	previous := sysfsRoot
+	sysfsRoot = root
+	t.Cleanup(func() { sysfsRoot = previous })
+	return root
+}
+
+func writeSysfs(t *testing.T, root, name, relative, content string) {
+	t.Helper()
+	path := filepath.Join(root, "class", "block", name, relative)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSysfsBlockNameAndWholeDiskResolvePartitionParent(t *testing.T) {
+	root := fakeSysfs(t, map[string]string{"sda": "pci/block/sda", "sda1": "pci/block/sda/sda1", "md0": "virtual/block/md0"})
+	writeSysfs(t, root, "sda1", "partition", "1\n")
+	os.Symlink(filepath.Join(root, "class", "block", "sda1"), filepath.Join(root, "dev", "block", "8:1"))
+	os.Symlink(filepath.Join(root, "class", "block", "md0"), filepath.Join(root, "dev", "block", "9:0"))
+	for id, want := range map[string]string{"8:1": "sda1", "9:0": "md0", "0:45": ""} {
+		if got := sysfsBlockName(id); got != want {
+			t.Fatalf("sysfsBlockName(%s) = %q, want %q", id, got, want)
+		}
+	}
+	for name, want := range map[string]string{"sda1": "sda", "sda": "sda", "md0": "md0", "missing": "missing"} {
+		if got := wholeDisk(name); got != want {
+			t.Fatalf("wholeDisk(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestResolveLayersFollowsSlaves(t *testing.T) {
+	root := fakeSysfs(t, map[string]string{
+		"dm-0": "virtual/block/dm-0", "md0": "virtual/block/md0",
+		"nvme0n1": "pci/nvme0/nvme0n1", "nvme0n1p2": "pci/nvme0/nvme0n1/nvme0n1p2",
+		"nvme1n1": "pci/nvme1/nvme1n1", "nvme1n1p2": "pci/nvme1/nvme1n1/nvme1n1p2",
+	})
+	writeSysfs(t, root, "dm-0", "dm/uuid", "CRYPT-LUKS2-0123-root\n")
+	writeSysfs(t, root, "dm-0", "slaves/md0", "")
+	writeSysfs(t, root, "md0", "md/level", "raid1\n")
+	writeSysfs(t, root, "md0", "slaves/nvme0n1p2", "")
+	writeSysfs(t, root, "md0", "slaves/nvme1n1p2", "")
+	writeSysfs(t, root, "nvme0n1p2", "partition", "2\n")
+	writeSysfs(t, root, "nvme1n1p2", "partition", "2\n")
+	writeSysfs(t, root, "nvme0n1p2", "holders/md0", "")
+	stack := StorageStack{FsType: "ext4", Layers: resolveLayers("dm-0")}
+	if got, want := stack.String(), "ext4 → dm-crypt → md raid1 → 2×nvme"; got != want {
+		t.Fatalf("stack = %q, want %q", got, want)
+	}
+	if members := stack.Members(); len(members) != 2 || members[0] != "/dev/nvme0n1" {
+		t.Fatalf("unexpected members %v", members)
+	}
+	if holders := partitionHolders("nvme0n1"); len(holders) != 1 || holders[0] != "md0" {
+		t.Fatalf("unexpected holders %v", holders)
+	}
+}
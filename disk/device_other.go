@@ -0,0 +1,18 @@
+//go:build !linux
+
+package disk
+
+// mountDevice 非Linux系统不解析设备号，仅依靠挂载源去重
+func mountDevice(mountPoint, source string) (deviceID, parentDisk string) {
+	return "", ""
+}
+
+// storageStack 非Linux系统不解析设备栈
+func storageStack(mountPoint, source string) StorageStack {
+	return StorageStack{}
+}
+
+// deviceHolders 非Linux系统不解析设备持有者
+func deviceHolders(path string) []string {
+	return nil
+}
@@ -0,0 +1,30 @@
+package disk
+
+import (
+	"os/exec"
+	"runtime"
+	"slices"
+)
+
+// embeddedFioPlatforms 与github.com/oneclickvirt/fio内置二进制覆盖的平台一致
+var embeddedFioPlatforms = []string{
+	"darwin/amd64", "darwin/arm64", "freebsd/amd64", "windows/386", "windows/amd64",
+	"linux/386", "linux/amd64", "linux/arm64", "linux/arm", "linux/mips", "linux/mips64",
+	"linux/mips64le", "linux/mipsle", "linux/ppc64", "linux/ppc64le", "linux/riscv64", "linux/s390x",
+}
+
+// embeddedDDPlatforms 与github.com/oneclickvirt/dd内置二进制覆盖的平台一致
+var embeddedDDPlatforms = []string{
+	"darwin/amd64", "darwin/arm64", "windows/386", "windows/amd64",
+	"linux/386", "linux/amd64", "linux/arm64", "linux/arm", "linux/mips", "linux/mips64",
+	"linux/mips64le", "linux/mipsle", "linux/ppc64", "linux/ppc64le", "linux/riscv64",
+}
+
+// lookupBinary 不解压、不执行地判断命令可用：系统PATH中存在，或当前平台有内置二进制
+func lookupBinary(name string, embeddedPlatforms []string) error {
+	_, err := exec.LookPath(name)
+	if err == nil || slices.Contains(embeddedPlatforms, runtime.GOOS+"/"+runtime.GOARCH) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,156 @@
+package disk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// DiscoveryFilter adjusts which mounts test-path discovery selects on top of
+// the built-in rules. A mount matching any include rule is selected even when
+// a built-in or configured exclusion matches it; MinSizeBytes always applies.
+//
+// Mounts sharing a filesystem with an already selected mount are always
+// skipped; OnePerDisk also skips further partitions of the same whole disk.
+//
+// Mount patterns are path.Match globs such as "/var/lib/docker/volumes/*";
+// a pattern without glob characters matches the path and everything below
+// it. Device rules are regular expressions matched against the device name.
+type DiscoveryFilter struct {
+	IncludeFsTypes []string `json:"include_fs_types,omitempty"`
+	ExcludeFsTypes []string `json:"exclude_fs_types,omitempty"`
+	IncludeMounts  []string `json:"include_mounts,omitempty"`
+	ExcludeMounts  []string `json:"exclude_mounts,omitempty"`
+	IncludeDevice  string   `json:"include_device,omitempty"`
+	ExcludeDevice  string   `json:"exclude_device,omitempty"`
+	MinSizeBytes   uint64   `json:"min_size_bytes,omitempty"`
+	OnePerDisk     bool     `json:"one_per_disk,omitempty"`
+}
+
+// LoadDiscoveryFilter reads a DiscoveryFilter from a JSON file.
+func LoadDiscoveryFilter(filename string) (DiscoveryFilter, error) {
+	var filter DiscoveryFilter
+	file, err := os.Open(filename)
+	if err != nil {
+		return filter, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&filter); err != nil {
+		return DiscoveryFilter{}, fmt.Errorf("invalid discovery config: %w", err)
+	}
+	return filter, filter.Validate()
+}
+
+// Validate reports malformed mount globs or device expressions.
+func (filter DiscoveryFilter) Validate() error {
+	_, err := filter.compile()
+	return err
+}
+
+// Merge returns filter with the rules of other appended; a non-zero
+// MinSizeBytes or device expression in other replaces the one in filter and
+// OnePerDisk is set when either sets it.
+func (filter DiscoveryFilter) Merge(other DiscoveryFilter) DiscoveryFilter {
+	merged := DiscoveryFilter{
+		IncludeFsTypes: append(append([]string{}, filter.IncludeFsTypes...), other.IncludeFsTypes...),
+		ExcludeFsTypes: append(append([]string{}, filter.ExcludeFsTypes...), other.ExcludeFsTypes...),
+		IncludeMounts:  append(append([]string{}, filter.IncludeMounts...), other.IncludeMounts...),
+		ExcludeMounts:  append(append([]string{}, filter.ExcludeMounts...), other.ExcludeMounts...),
+		IncludeDevice:  filter.IncludeDevice,
+		ExcludeDevice:  filter.ExcludeDevice,
+		MinSizeBytes:   filter.MinSizeBytes,
+		OnePerDisk:     filter.OnePerDisk || other.OnePerDisk,
+	}
+	if other.IncludeDevice != "" {
+		merged.IncludeDevice = other.IncludeDevice
+	}
+	if other.ExcludeDevice != "" {
+		merged.ExcludeDevice = other.ExcludeDevice
+	}
+	if other.MinSizeBytes != 0 {
+		merged.MinSizeBytes = other.MinSizeBytes
+	}
+	return merged
+}
+
+// mountFilter 编译后的筛选规则
+type mountFilter struct {
+	DiscoveryFilter
+	includeDevice, excludeDevice *regexp.Regexp
+}
+
+func (filter DiscoveryFilter) compile() (mountFilter, error) {
+	compiled := mountFilter{DiscoveryFilter: filter}
+	for _, pattern := range append(append([]string{}, filter.IncludeMounts...), filter.ExcludeMounts...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return compiled, fmt.Errorf("invalid mount pattern %q: %w", pattern, err)
+		}
+	}
+	var err error
+	if filter.IncludeDevice != "" {
+		if compiled.includeDevice, err = regexp.Compile(filter.IncludeDevice); err != nil {
+			return compiled, fmt.Errorf("invalid include device expression: %w", err)
+		}
+	}
+	if filter.ExcludeDevice != "" {
+		if compiled.excludeDevice, err = regexp.Compile(filter.ExcludeDevice); err != nil {
+			return compiled, fmt.Errorf("invalid exclude device expression: %w", err)
+		}
+	}
+	return compiled, nil
+}
+
+// includes 判断分区是否命中任一包含规则
+func (filter mountFilter) includes(f disk.PartitionStat) bool {
+	return containsFsType(filter.IncludeFsTypes, f.Fstype) ||
+		matchMountPattern(filter.IncludeMounts, f.Mountpoint) ||
+		(filter.includeDevice != nil && filter.includeDevice.MatchString(f.Device))
+}
+
+// excludeReason 返回命中的自定义排除规则
+func (filter mountFilter) excludeReason(f disk.PartitionStat) string {
+	switch {
+	case containsFsType(filter.ExcludeFsTypes, f.Fstype):
+		return "filtered_fs_type"
+	case matchMountPattern(filter.ExcludeMounts, f.Mountpoint):
+		return "filtered_mount_point"
+	case filter.excludeDevice != nil && filter.excludeDevice.MatchString(f.Device):
+		return "filtered_device"
+	}
+	return ""
+}
+
+func containsFsType(fsTypes []string, fsType string) bool {
+	for _, candidate := range fsTypes {
+		if strings.EqualFold(strings.TrimSpace(candidate), fsType) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMountPattern 不含通配符的规则匹配该路径及其子路径
+func matchMountPattern(patterns []string, mountPoint string) bool {
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		if strings.ContainsAny(pattern, "*?[") {
+			if matched, _ := path.Match(pattern, mountPoint); matched {
+				return true
+			}
+			continue
+		}
+		if pattern == "/" || mountPoint == pattern || strings.HasPrefix(mountPoint, strings.TrimSuffix(pattern, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+func TestFilterMountReason(t *testing.T) {
+	rules, err := DiscoveryFilter{
+		IncludeMounts:  []string{"/var/lib/docker/volumes/*", "/mnt/nfs-fast"},
+		ExcludeFsTypes: []string{"NFS4"},
+		ExcludeMounts:  []string{"/srv/scratch"},
+		ExcludeDevice:  `^/dev/sdc`,
+	}.compile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		part disk.PartitionStat
+		want string
+	}{
+		{disk.PartitionStat{Device: "/dev/sdb1", Mountpoint: "/var/lib/docker/volumes/db", Fstype: "ext4"}, ""},
+		{disk.PartitionStat{Device: "/dev/sdb2", Mountpoint: "/var/lib/docker", Fstype: "ext4"}, "excluded_mount_point"},
+		{disk.PartitionStat{Device: "nas:/export", Mountpoint: "/mnt/nfs", Fstype: "nfs4"}, "filtered_fs_type"},
+		{disk.PartitionStat{Device: "nas:/fast", Mountpoint: "/mnt/nfs-fast/sub", Fstype: "nfs4"}, ""},
+		{disk.PartitionStat{Device: "/dev/sdb3", Mountpoint: "/srv/scratch/cache", Fstype: "xfs"}, "filtered_mount_point"},
+		{disk.PartitionStat{Device: "/dev/sdb4", Mountpoint: "/srv/scratch2", Fstype: "xfs"}, ""},
+		{disk.PartitionStat{Device: "/dev/sdc1", Mountpoint: "/data", Fstype: "xfs"}, "filtered_device"},
+	} {
+		if got := filterMountReason(test.part, rules); got != test.want {
+			t.Fatalf("filterMountReason(%+v) = %q, want %q", test.part, got, test.want)
+		}
+	}
+}
+
+func TestLoadDiscoveryFilter(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "filter.json")
+	os.WriteFile(valid, []byte(`{"exclude_fs_types":["nfs"],"min_size_bytes":1073741824}`), 0o644)
+	filter, err := LoadDiscoveryFilter(valid)
+	if err != nil || len(filter.ExcludeFsTypes) != 1 || filter.MinSizeBytes != 1<<30 {
+		t.Fatalf("unexpected filter %+v err=%v", filter, err)
+	}
+	merged := filter.Merge(DiscoveryFilter{ExcludeFsTypes: []string{"cifs"}})
+	if len(merged.ExcludeFsTypes) != 2 || merged.MinSizeBytes != 1<<30 || len(filter.ExcludeFsTypes) != 1 {
+		t.Fatalf("unexpected merge %+v", merged)
+	}
+	for name, content := range map[string]string{
+		"unknown.json": `{"exclude_fs":["nfs"]}`,
+		"regexp.json":  `{"exclude_device":"("}`,
+		"glob.json":    `{"include_mounts":["/data/["]}`,
+	} {
+		invalid := filepath.Join(dir, name)
+		os.WriteFile(invalid, []byte(content), 0o644)
+		if _, err := LoadDiscoveryFilter(invalid); err == nil {
+			t.Fatalf("LoadDiscoveryFilter accepted %s", content)
+		}
+	}
+}
+
+func TestDiscoverMountsMinSize(t *testing.T) {
+	mounts, err := DiscoverMountsWithFilter(DiscoveryFilter{MinSizeBytes: 1 << 62})
+	if err != nil {
+		t.Skipf("partitions unavailable: %v", err)
+	}
+	for _, mount := range mounts {
+		if mount.Excluded == "" {
+			t.Fatalf("mount below the minimum size was selected: %+v", mount)
+		}
+	}
+}
@@ -1,17 +1,15 @@
 package disk
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/oneclickvirt/fio"
 	"github.com/shirou/gopsutil/disk"
 )
 
@@ -43,33 +41,172 @@ func generateFioTestHeader(language string, actualTestPaths []string) string {
 	return header
 }
 
+// FioBlockResult is one legacy FIO row: a 50/50 random read/write run at one
+// block size on one path. Bandwidth is in bytes/s.
+type FioBlockResult struct {
+	Path                string  `json:"path"`
+	BlockSize           string  `json:"block_size"`
+	ReadBytesPerSecond  float64 `json:"read_bytes_per_second"`
+	WriteBytesPerSecond float64 `json:"write_bytes_per_second"`
+	TotalBytesPerSecond float64 `json:"total_bytes_per_second"`
+	ReadIOPS            float64 `json:"read_iops"`
+	WriteIOPS           float64 `json:"write_iops"`
+	TotalIOPS           float64 `json:"total_iops"`
+}
+
+// FioLegacyResult is the typed outcome of the legacy FIO benchmark. Status is
+// ok, partial (rows plus errors), unavailable (no rows), or canceled/timeout
+// when the context stopped the run; rows finished before that are kept.
+type FioLegacyResult struct {
+	Status  string           `json:"status"`
+	Results []FioBlockResult `json:"results,omitempty"`
+	Errors  []string         `json:"errors,omitempty"`
+	Error   string           `json:"error,omitempty"`
+	// DataPattern is the written data; empty means fio's default buffers.
+	DataPattern string `json:"data_pattern,omitempty"`
+	// Aliases lists mounts a multi-disk run skipped as duplicates.
+	Aliases []MountAlias `json:"aliases,omitempty"`
+	// Stacks maps tested paths on LVM, dm-crypt or md devices to the
+	// device stack below them.
+	Stacks map[string]StorageStack `json:"stacks,omitempty"`
+	// Throttles maps tested paths to the cgroup v2 I/O settings limiting
+	// them.
+	Throttles map[string]IOThrottle `json:"throttles,omitempty"`
+	// Scores maps tested paths to a score computed from the 4k and 1m rows.
+	Scores map[string]Score `json:"scores,omitempty"`
+	// Units is the unit system RenderFioLegacy uses for bandwidth.
+	Units UnitFormat `json:"units"`
+	// Fio is the fio binary the test ran with.
+	Fio *FioInfo `json:"fio,omitempty"`
+}
+
 // FioTest 通过fio测试硬盘
 func FioTest(language string, enableMultiCheck bool, testPath string) string {
+	return RunTest(TestOptions{Method: "fio", Language: language, MultiCheck: enableMultiCheck, Paths: []string{testPath}}).Text
+}
+
+// FioTestContext runs the legacy FIO benchmark while honoring cancellation
+// and deadlines: running fio processes are killed, test.fio files removed and
+// the table ends with a canceled or timed-out note.
+func FioTestContext(ctx context.Context, language string, enableMultiCheck bool, testPath string) string {
+	return RunTest(TestOptions{Context: ctx, Method: "fio", Language: language, MultiCheck: enableMultiCheck, Paths: []string{testPath}}).Text
+}
+
+// RenderFioLegacy renders a typed legacy FIO result as the localized
+// fixed-width table returned by FioTest.
+func RenderFioLegacy(language string, result FioLegacyResult) string {
+	if len(result.Results) == 0 && result.Error == "test_path_create_failed" {
+		return localizedText(language, "创建测试路径失败", "Unable to create test path") + "\n"
+	}
+	blocks := make([]string, 0, len(result.Results))
+	for _, row := range result.Results {
+		blocks = append(blocks, renderFioRow(row, result.Units))
+	}
+	text := renderLegacyResults(language, blocks, generateFioTestHeader)
+	if text != "" {
+		text += renderUnitNote(language, result.Units)
+	}
+	text += renderMountAliases(language, result.Aliases) + renderStorageStacks(language, result.Stacks) +
+		renderThrottles(language, result.Units, result.Throttles) + renderScores(language, result.Scores) + renderFioInfo(language, result.Fio)
+	switch result.Status {
+	case "canceled":
+		text += localizedText(language, "FIO测试已取消", "FIO test canceled") + "\n"
+	case "timeout":
+		text += localizedText(language, "FIO测试超时", "FIO test timed out") + "\n"
+	}
+	return text
+}
+
+// FioTestResults runs the legacy FIO benchmark and returns numbers instead of
+// preformatted strings, for programs embedding disktest.
+func FioTestResults(enableMultiCheck bool, testPath string) FioLegacyResult {
+	return runFioLegacy(context.Background(), fioLegacyConfig{}, enableMultiCheck, testPath)
+}
+
+// fioLegacyConfig carries the tunable parts of the legacy FIO run; zero
+// values keep the historical defaults.
+type fioLegacyConfig struct {
+	size       string
+	runtime    time.Duration
+	blockSizes []string
+	ioDepth    int
+	numJobs    int
+	pattern    DataPattern
+	filter     DiscoveryFilter
+	scoreTable *ScoreTable
+	units      UnitFormat
+	// binary 指定的fio路径，为空时使用DISKTEST_FIO或内置fio
+	binary string
+}
+
+func (config fioLegacyConfig) withDefaults() fioLegacyConfig {
+	if config.size == "" {
+		if runtime.GOARCH == "arm64" || runtime.GOARCH == "arm" {
+			config.size = "512M"
+		} else {
+			config.size = "2G"
+		}
+	}
+	if config.runtime <= 0 {
+		config.runtime = 30 * time.Second
+	}
+	if len(config.blockSizes) == 0 {
+		config.blockSizes = []string{"4k", "64k", "512k", "1m"}
+	}
+	if config.ioDepth <= 0 {
+		config.ioDepth = 64
+	}
+	if config.numJobs <= 0 {
+		config.numJobs = 2
+	}
+	return config
+}
+
+func runFioLegacy(ctx context.Context, config fioLegacyConfig, enableMultiCheck bool, testPath string) (result FioLegacyResult) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	config = config.withDefaults()
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
 		Logger.Info("开始FIO测试硬盘")
 	}
-	var actualResults []string // 存储实际测试结果
-	pathInfo, err := getTestPaths()
+	result.DataPattern, result.Units = config.pattern.String(), config.units.resolved()
+	defer func() {
+		for _, row := range result.Results {
+			checkThrottle(result.Throttles, row.Path, "read", row.ReadBytesPerSecond, row.ReadIOPS)
+			checkThrottle(result.Throttles, row.Path, "write", row.WriteBytesPerSecond, row.WriteIOPS)
+		}
+		table := DefaultLegacyScoreTable()
+		if config.scoreTable != nil {
+			table = *config.scoreTable
+		}
+		result.Scores = legacyScores(table, result.Results, result.Throttles)
+		result.Status = legacyResultStatus(len(result.Results), len(result.Errors))
+		if ctx.Err() != nil {
+			result.Status, result.Error = matrixStopStatus(ctx.Err()), stableMatrixError(ctx.Err())
+		}
+	}()
+	if ctx.Err() != nil {
+		return result
+	}
+	pathInfo, err := getTestPaths(config.filter)
 	if err != nil {
 		if EnableLoger {
 			Logger.Info("FioTest err: " + err.Error())
 		}
-		return ""
+		result.Error = "test_path_discovery_failed"
+		return result
 	}
 	devices := pathInfo.Devices
 	mountPoints := pathInfo.MountPoints
 	var actualTestPaths []string
-	var defaultFioSize string
-	if runtime.GOARCH == "arm64" || runtime.GOARCH == "arm" {
-		defaultFioSize = "512M"
-	} else {
-		defaultFioSize = "2G"
-	}
+	defaultFioSize := config.size
 	if testPath == "" {
 		if enableMultiCheck {
 			actualTestPaths = mountPoints
+			result.Aliases = pathInfo.Aliases
 		} else {
 			rootPath, tmpPath := getDefaultTestPaths()
 			actualTestPaths = []string{rootPath} // 默认先使用rootPath，实际测试中可能会切换到tmpPath
@@ -90,10 +227,30 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 	} else {
 		actualTestPaths = []string{testPath}
 	}
+	result.Stacks = resolveStacks(actualTestPaths)
+	result.Throttles = detectThrottles(actualTestPaths)
+	acquired, err := acquireLegacyFio(ctx, config.binary)
+	if acquired.Cleanup != nil {
+		defer func() { _ = acquired.Cleanup() }()
+	}
+	if err != nil {
+		loggerInsert(Logger, "fio不可用: "+err.Error())
+		result.Error = "fio_unavailable"
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	loggerInsert(Logger, "使用fio: "+acquired.Info.Path+" "+acquired.Info.Version)
+	result.Fio = &acquired.Info
+	fioCommand := acquired.Command
+	ioEngine := checkFioIOEngine(ctx, fioCommand, acquired.Info)
+	loggerInsert(Logger, "使用IO引擎: "+ioEngine)
 	if testPath == "" {
 		if enableMultiCheck {
 			loggerInsert(Logger, "开始多路径FIO测试")
 			for index, path := range mountPoints {
+				if ctx.Err() != nil {
+					break
+				}
 				deviceName := path
 				if index < len(devices) {
 					deviceName = devices[index]
@@ -105,22 +262,24 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 				}
 				fioSize := adjustFioTestSize(path, defaultFioSize)
 				loggerInsert(Logger, "FIO测试文件大小: "+fioSize)
-				buildOutput, err := buildFioFile(path, fioSize)
-				defer os.Remove(filepath.Join(path, "test.fio"))
+				buildOutput, err := buildFioFile(ctx, fioCommand, ioEngine, path, fioSize)
+				defer removeArtifact(filepath.Join(path, "test.fio"))
 				if err == nil {
 					if buildOutput != "" {
 						loggerInsert(Logger, "生成FIO测试文件输出: "+buildOutput)
 					}
-					time.Sleep(1 * time.Second)
-					tempResult, err := execFioTest(path, path, fioSize)
-					if strings.TrimSpace(tempResult) != "" {
-						actualResults = append(actualResults, tempResult)
+					if !sleepContext(ctx, time.Second) {
+						break
 					}
+					rows, err := execFioTest(ctx, fioCommand, ioEngine, path, path, fioSize, config)
+					result.Results = append(result.Results, rows...)
 					if err != nil {
 						loggerInsert(Logger, "执行FIO测试失败: "+err.Error())
+						result.Errors = append(result.Errors, err.Error())
 					}
 				} else {
 					loggerInsert(Logger, "生成FIO测试文件失败: "+err.Error())
+					result.Errors = append(result.Errors, err.Error())
 				}
 			}
 		} else {
@@ -133,8 +292,8 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 			}
 			rootFioSize := adjustFioTestSize(rootPath, defaultFioSize)
 			loggerInsert(Logger, rootPath+"路径FIO测试文件大小: "+rootFioSize)
-			tempText, err := buildFioFile(rootPath, rootFioSize)
-			defer os.Remove(filepath.Join(rootPath, "test.fio"))
+			tempText, err := buildFioFile(ctx, fioCommand, ioEngine, rootPath, rootFioSize)
+			defer removeArtifact(filepath.Join(rootPath, "test.fio"))
 			if err != nil || strings.Contains(tempText, "failed") || strings.Contains(tempText, "Permission denied") || strings.Contains(tempText, "No such file or directory") {
 				if EnableLoger {
 					Logger.Info("在" + rootPath + "路径生成FIO测试文件失败，尝试" + tmpPath + "路径")
@@ -150,8 +309,8 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 				}
 				tmpFioSize := adjustFioTestSize(tmpPath, defaultFioSize)
 				loggerInsert(Logger, tmpPath+"路径FIO测试文件大小: "+tmpFioSize)
-				buildOutput, err := buildFioFile(tmpPath, tmpFioSize)
-				defer os.Remove(filepath.Join(tmpPath, "test.fio"))
+				buildOutput, err := buildFioFile(ctx, fioCommand, ioEngine, tmpPath, tmpFioSize)
+				defer removeArtifact(filepath.Join(tmpPath, "test.fio"))
 				if err == nil {
 					buildPath = tmpPath
 					fioSize = tmpFioSize
@@ -170,17 +329,21 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 			}
 			if buildPath != "" {
 				loggerInsert(Logger, "使用路径进行FIO测试: "+buildPath)
-				time.Sleep(1 * time.Second)
-				tempResult, err := execFioTest(buildPath, buildPath, fioSize)
-				if strings.TrimSpace(tempResult) != "" {
-					actualResults = append(actualResults, tempResult)
+				if !sleepContext(ctx, time.Second) {
+					return result
 				}
-				if err != nil && EnableLoger {
-					Logger.Info("执行FIO测试失败: " + err.Error())
+				rows, err := execFioTest(ctx, fioCommand, ioEngine, buildPath, buildPath, fioSize, config)
+				result.Results = append(result.Results, rows...)
+				if err != nil {
+					loggerInsert(Logger, "执行FIO测试失败: "+err.Error())
+					result.Errors = append(result.Errors, err.Error())
 				}
 			}
 			// 检查是否有大于210GB的路径需要额外测试
 			for _, path := range mountPoints {
+				if ctx.Err() != nil {
+					break
+				}
 				if path == rootPath || path == tmpPath {
 					continue // 跳过已经测试过的默认路径
 				}
@@ -199,22 +362,24 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 					}
 					fioSize := adjustFioTestSize(path, defaultFioSize)
 					loggerInsert(Logger, "大容量路径FIO测试文件大小: "+fioSize)
-					buildOutput, err := buildFioFile(path, fioSize)
-					defer os.Remove(filepath.Join(path, "test.fio"))
+					buildOutput, err := buildFioFile(ctx, fioCommand, ioEngine, path, fioSize)
+					defer removeArtifact(filepath.Join(path, "test.fio"))
 					if err == nil {
 						if buildOutput != "" {
 							loggerInsert(Logger, "生成大容量路径FIO测试文件输出: "+buildOutput)
 						}
-						time.Sleep(1 * time.Second)
-						tempResult, err := execFioTest(path, path, fioSize)
-						if strings.TrimSpace(tempResult) != "" {
-							actualResults = append(actualResults, tempResult)
+						if !sleepContext(ctx, time.Second) {
+							break
 						}
+						rows, err := execFioTest(ctx, fioCommand, ioEngine, path, path, fioSize, config)
+						result.Results = append(result.Results, rows...)
 						if err != nil {
 							loggerInsert(Logger, "执行大容量路径FIO测试失败: "+err.Error())
+							result.Errors = append(result.Errors, err.Error())
 						}
 					} else {
 						loggerInsert(Logger, "生成大容量路径FIO测试文件失败: "+err.Error())
+						result.Errors = append(result.Errors, err.Error())
 					}
 				}
 			}
@@ -223,12 +388,13 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 		loggerInsert(Logger, "测试指定路径: "+testPath)
 		if err := ensurePathExists(testPath); err != nil {
 			loggerInsert(Logger, "创建指定路径失败: "+testPath+", 错误: "+err.Error())
-			return localizedText(language, "创建测试路径失败", "Unable to create test path") + "\n"
+			result.Error = "test_path_create_failed"
+			return result
 		}
 		fioSize := adjustFioTestSize(testPath, defaultFioSize)
 		loggerInsert(Logger, "指定路径FIO测试文件大小: "+fioSize)
-		tempText, err := buildFioFile(testPath, fioSize)
-		defer os.Remove(filepath.Join(testPath, "test.fio"))
+		tempText, err := buildFioFile(ctx, fioCommand, ioEngine, testPath, fioSize)
+		defer removeArtifact(filepath.Join(testPath, "test.fio"))
 		if err != nil || strings.Contains(tempText, "failed") || strings.Contains(tempText, "Permission denied") || strings.Contains(tempText, "No such file or directory") {
 			if EnableLoger {
 				Logger.Info("在指定路径生成FIO测试文件失败")
@@ -239,21 +405,23 @@ func FioTest(language string, enableMultiCheck bool, testPath string) string {
 					Logger.Info("输出: " + tempText)
 				}
 			}
-			return ""
+			result.Error = "fio_prepare_failed"
+			return result
 		}
 		if EnableLoger && tempText != "" {
 			Logger.Info("在指定路径生成FIO测试文件输出: " + tempText)
 		}
-		time.Sleep(1 * time.Second)
-		tempResult, err := execFioTest(testPath, testPath, fioSize)
-		if strings.TrimSpace(tempResult) != "" {
-			actualResults = append(actualResults, tempResult)
+		if !sleepContext(ctx, time.Second) {
+			return result
 		}
-		if err != nil && EnableLoger {
-			Logger.Info("执行FIO测试失败: " + err.Error())
+		rows, err := execFioTest(ctx, fioCommand, ioEngine, testPath, testPath, fioSize, config)
+		result.Results = append(result.Results, rows...)
+		if err != nil {
+			loggerInsert(Logger, "执行FIO测试失败: "+err.Error())
+			result.Errors = append(result.Errors, err.Error())
 		}
 	}
-	return renderLegacyResults(language, actualResults, generateFioTestHeader)
+	return result
 }
 
 // adjustFioTestSize 根据可用磁盘空间调整FIO测试文件大小
@@ -263,11 +431,10 @@ func adjustFioTestSize(testPath, defaultSize string) string {
 		loggerInsert(Logger, "获取磁盘使用情况失败: "+err.Error()+", 使用默认测试大小")
 		return defaultSize
 	}
-	var requiredBytes uint64
-	if defaultSize == "512M" {
-		requiredBytes = 512 * 1024 * 1024
-	} else { // defaultSize == "2G"
-		requiredBytes = 2 * 1024 * 1024 * 1024
+	requiredBytes, err := parseSizeBytes(defaultSize)
+	if err != nil {
+		loggerInsert(Logger, "无法解析FIO测试大小"+defaultSize+": "+err.Error()+", 使用默认测试大小")
+		return defaultSize
 	}
 	availableBytes := usage.Free
 	if availableBytes < requiredBytes*3/2 {
@@ -276,26 +443,17 @@ func adjustFioTestSize(testPath, defaultSize string) string {
 		if testSizeBytes < minSizeBytes {
 			testSizeBytes = minSizeBytes
 		}
-		maxSizeBytes := uint64(2 * 1024 * 1024 * 1024)
+		maxSizeBytes := min(uint64(2*1024*1024*1024), max(requiredBytes, minSizeBytes))
 		if testSizeBytes > maxSizeBytes {
 			testSizeBytes = maxSizeBytes
 		}
 		sizeStr := ""
-		if defaultSize == "512M" {
-			sizeMB := int(testSizeBytes / (1024 * 1024))
-			sizeStr = fmt.Sprintf("%dM", sizeMB)
-			loggerInsert(Logger, fmt.Sprintf("调整FIO测试大小从512M到%dM", sizeMB))
+		if testSizeBytes >= 1024*1024*1024 {
+			sizeStr = fmt.Sprintf("%.1fG", float64(testSizeBytes)/(1024*1024*1024))
 		} else {
-			if testSizeBytes >= 1024*1024*1024 {
-				sizeGB := float64(testSizeBytes) / (1024 * 1024 * 1024)
-				sizeStr = fmt.Sprintf("%.1fG", sizeGB)
-				loggerInsert(Logger, fmt.Sprintf("调整FIO测试大小从2G到%.1fG", sizeGB))
-			} else {
-				sizeMB := int(testSizeBytes / (1024 * 1024))
-				sizeStr = fmt.Sprintf("%dM", sizeMB)
-				loggerInsert(Logger, fmt.Sprintf("调整FIO测试大小从2G到%dM", sizeMB))
-			}
+			sizeStr = fmt.Sprintf("%dM", int(testSizeBytes/(1024*1024)))
 		}
+		loggerInsert(Logger, "调整FIO测试大小从"+defaultSize+"到"+sizeStr)
 		return sizeStr
 	}
 	loggerInsert(Logger, fmt.Sprintf("可用空间充足(%d字节)，使用默认测试大小%s", availableBytes, defaultSize))
@@ -303,28 +461,20 @@ func adjustFioTestSize(testPath, defaultSize string) string {
 }
 
 // buildFioFile 生成对应文件
-func buildFioFile(path, fioSize string) (string, error) {
+func buildFioFile(ctx context.Context, fioCommand []string, ioEngine, path, fioSize string) (string, error) {
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
 		Logger.Info("开始生成FIO测试文件，路径: " + path + ", 大小: " + fioSize)
 	}
-	var args []string
-	embeddedCmd, embeddedPath, err := fio.GetFIO()
-	defer fio.CleanFio(embeddedPath)
-	if err == nil {
-		loggerInsert(Logger, "使用嵌入的fio二进制文件: "+embeddedPath)
-	} else {
-		loggerInsert(Logger, "fio不可用: "+err.Error())
-		return "", err
-	}
-	args = splitCommand(embeddedCmd)
-	if len(args) == 0 {
+	if len(fioCommand) == 0 {
 		return "", fmt.Errorf("fio command is empty")
 	}
+	args := append([]string{}, fioCommand...)
 	testFilePath := filepath.Join(path, "test.fio")
-	args = append(args, "--name=setup", "--ioengine="+checkFioIOEngine(), "--rw=read", "--bs=64k", "--iodepth=64", "--numjobs=2", "--size="+fioSize, "--runtime=1", "--gtod_reduce=1", "--filename="+testFilePath, "--direct=1", "--minimal")
-	cmd1 := exec.Command(args[0], args[1:]...)
+	markArtifact(testFilePath)
+	args = append(args, fioSetupArgs(ioEngine, fioSize, testFilePath)...)
+	cmd1 := benchmarkCommand(ctx, args[0], args[1:]...)
 	stderr1, err := cmd1.StderrPipe()
 	if err != nil {
 		loggerInsert(Logger, "failed to get stderr pipe: "+err.Error())
@@ -350,101 +500,51 @@ func buildFioFile(path, fioSize string) (string, error) {
 }
 
 // execFioTest 使用fio测试文件进行测试
-func execFioTest(path, devicename, fioSize string) (string, error) {
+func execFioTest(ctx context.Context, baseArgs []string, ioEngine, path, devicename, fioSize string, config fioLegacyConfig) ([]FioBlockResult, error) {
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
 		Logger.Info("开始执行FIO测试，路径: " + path + ", 设备: " + devicename + ", 大小: " + fioSize)
 	}
-	var result string
-	var baseArgs []string
-	// 获取可用的IO引擎
-	ioEngine := checkFioIOEngine()
-	loggerInsert(Logger, "使用IO引擎: "+ioEngine)
-	embeddedCmd, embeddedPath, err := fio.GetFIO()
-	defer fio.CleanFio(embeddedPath)
-	if err == nil {
-		loggerInsert(Logger, "使用嵌入的fio二进制文件: "+embeddedPath)
-	} else {
-		loggerInsert(Logger, "fio不可用: "+err.Error())
-		return "", err
-	}
-	baseArgs = splitCommand(embeddedCmd)
+	var result []FioBlockResult
 	if len(baseArgs) == 0 {
-		return "", fmt.Errorf("fio command is empty")
+		return nil, fmt.Errorf("fio command is empty")
 	}
 	testFilePath := filepath.Join(path, "test.fio")
-	blockSizes := []string{"4k", "64k", "512k", "1m"}
+	blockSizes := config.blockSizes
+	runtimeSeconds := max(int(config.runtime.Seconds()), 1)
 	var firstErr error
 	for _, BS := range blockSizes {
-		loggerInsert(Logger, "开始测试块大小: "+BS)
-		var args []string
-		if commandExists("timeout") {
-			args = append(args, "35")
+		if err := ctx.Err(); err != nil {
+			return result, err
 		}
-		var fioArgs []string
-		if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
-			fioArgs = []string{
-				"--name=rand_rw_" + BS,
-				"--ioengine=" + ioEngine,
-				"--rw=randrw",
-				"--rwmixread=50",
-				"--bs=" + BS,
-				"--iodepth=64",
-				"--numjobs=2",
-				"--size=" + fioSize,
-				"--runtime=30",
-				"--direct=0",
-				"--filename=" + testFilePath,
-				"--group_reporting",
-				"--minimal",
-			}
-		} else {
-			fioArgs = []string{
-				"--name=rand_rw_" + BS,
-				"--ioengine=" + ioEngine,
-				"--rw=randrw",
-				"--rwmixread=50",
-				"--bs=" + BS,
-				"--iodepth=64",
-				"--numjobs=2",
-				"--size=" + fioSize,
-				"--runtime=30",
-				"--gtod_reduce=1",
-				"--direct=1",
-				"--filename=" + testFilePath,
-				"--group_reporting",
-				"--minimal",
-			}
-		}
-		var output []byte
-		var runErr error
-		if commandExists("timeout") && runtime.GOOS != "windows" {
-			cmd2 := exec.Command("timeout", append(args, append(baseArgs, fioArgs...)...)...)
-			output, runErr = cmd2.CombinedOutput()
-		} else {
-			cmd2 := exec.Command(baseArgs[0], append(baseArgs[1:], fioArgs...)...)
-			output, runErr = cmd2.CombinedOutput()
-		}
-		parsed := processFioOutput(string(output), BS, devicename)
-		if parsed != "" {
-			result += parsed
+		loggerInsert(Logger, "开始测试块大小: "+BS)
+		fioArgs := legacyFioArgs(BS, ioEngine, fioSize, testFilePath, runtimeSeconds, config)
+		// 每个块大小最多运行runtime+5秒，超时或取消时结束fio进程
+		blockCtx, cancel := context.WithTimeout(ctx, time.Duration(runtimeSeconds+5)*time.Second)
+		cmd2 := benchmarkCommand(blockCtx, baseArgs[0], append(baseArgs[1:], fioArgs...)...)
+		output, runErr := cmd2.CombinedOutput()
+		cancel()
+		if err := ctx.Err(); err != nil {
+			return result, err
 		}
+		parsed := parseFioTerse(string(output), BS, devicename)
+		result = append(result, parsed...)
 		if runErr != nil {
 			loggerInsert(Logger, "failed to execute fio command: "+runErr.Error())
-			if result == "" {
-				return "", runErr
+			if len(result) == 0 {
+				return nil, runErr
 			}
 			if firstErr == nil {
 				firstErr = runErr
 			}
 			continue
 		}
-		if parsed == "" {
+		if len(parsed) == 0 {
 			parseErr := fmt.Errorf("fio output contains no result for block size %s", BS)
 			loggerInsert(Logger, parseErr.Error())
-			if result == "" {
-				return "", parseErr
+			if len(result) == 0 {
+				return nil, parseErr
 			}
 			if firstErr == nil {
 				firstErr = parseErr
@@ -454,9 +554,54 @@ func execFioTest(path, devicename, fioSize string) (string, error) {
 	return result, firstErr
 }
 
-// processFioOutput 处理fio输出结果
-func processFioOutput(tempText, BS, devicename string) string {
-	var result string
+// fioSetupArgs 生成预先写出test.fio测试文件的fio参数
+func fioSetupArgs(ioEngine, fioSize, testFilePath string) []string {
+	return []string{"--name=setup", "--ioengine=" + ioEngine, "--rw=read", "--bs=64k", "--iodepth=64", "--numjobs=2", "--size=" + fioSize, "--runtime=1", "--gtod_reduce=1", "--filename=" + testFilePath, "--direct=1", "--minimal"}
+}
+
+// legacyFioArgs 生成传统FIO表格单个块大小的随机读写参数
+func legacyFioArgs(BS, ioEngine, fioSize, testFilePath string, runtimeSeconds int, config fioLegacyConfig) []string {
+	var fioArgs []string
+	if runtime.GOOS == "darwin" || runtime.GOOS == "windows" {
+		fioArgs = []string{
+			"--name=rand_rw_" + BS,
+			"--ioengine=" + ioEngine,
+			"--rw=randrw",
+			"--rwmixread=50",
+			"--bs=" + BS,
+			"--iodepth=" + strconv.Itoa(config.ioDepth),
+			"--numjobs=" + strconv.Itoa(config.numJobs),
+			"--size=" + fioSize,
+			"--runtime=" + strconv.Itoa(runtimeSeconds),
+			"--direct=0",
+			"--filename=" + testFilePath,
+			"--group_reporting",
+			"--minimal",
+		}
+	} else {
+		fioArgs = []string{
+			"--name=rand_rw_" + BS,
+			"--ioengine=" + ioEngine,
+			"--rw=randrw",
+			"--rwmixread=50",
+			"--bs=" + BS,
+			"--iodepth=" + strconv.Itoa(config.ioDepth),
+			"--numjobs=" + strconv.Itoa(config.numJobs),
+			"--size=" + fioSize,
+			"--runtime=" + strconv.Itoa(runtimeSeconds),
+			"--gtod_reduce=1",
+			"--direct=1",
+			"--filename=" + testFilePath,
+			"--group_reporting",
+			"--minimal",
+		}
+	}
+	return append(fioArgs, config.pattern.fioArgs()...)
+}
+
+// parseFioTerse 解析fio terse输出中对应块大小的行，带宽由KiB/s换算为bytes/s
+func parseFioTerse(tempText, BS, devicename string) []FioBlockResult {
+	var result []FioBlockResult
 	loggerInsert(Logger, "FIO测试原始输出("+BS+"): "+tempText)
 	tempList := strings.Split(tempText, "\n")
 	for _, l := range tempList {
@@ -482,18 +627,31 @@ func processFioOutput(tempText, BS, devicename string) string {
 			loggerInsert(Logger, "块大小: "+BS+", 读取IOPS: "+DISK_IOPS_R+", 写入IOPS: "+DISK_IOPS_W+
 				", 总IOPS: "+strconv.Itoa(DISK_IOPS)+", 读取速度: "+DISK_TEST_R+
 				", 写入速度: "+DISK_TEST_W+", 总速度: "+fmt.Sprintf("%f", DISK_TEST))
-			deviceWidth := getMountPointColumnWidth(devicename)
-			if deviceWidth < 15 {
-				deviceWidth = 15
-			}
-			// 拼接输出文本
-			result += fmt.Sprintf("%-*s   %-7s   %-23s %-23s %-23s\n",
-				deviceWidth, devicename,
-				BS,
-				formatSpeed(DISK_TEST_R, "string")+"("+formatIOPS(DISK_IOPS_R, "string")+")",
-				formatSpeed(DISK_TEST_W, "string")+"("+formatIOPS(DISK_IOPS_W, "string")+")",
-				formatSpeed(DISK_TEST, "float64")+"("+formatIOPS(DISK_IOPS, "int")+")")
+			result = append(result, FioBlockResult{
+				Path:                devicename,
+				BlockSize:           BS,
+				ReadBytesPerSecond:  DISK_TEST_R_INT * 1024,
+				WriteBytesPerSecond: DISK_TEST_W_INT * 1024,
+				TotalBytesPerSecond: DISK_TEST * 1024,
+				ReadIOPS:            float64(DISK_IOPS_R_INT),
+				WriteIOPS:           float64(DISK_IOPS_W_INT),
+				TotalIOPS:           float64(DISK_IOPS),
+			})
 		}
 	}
 	return result
 }
+
+// renderFioRow 拼接单行输出文本
+func renderFioRow(row FioBlockResult, units UnitFormat) string {
+	deviceWidth := getMountPointColumnWidth(row.Path)
+	if deviceWidth < 15 {
+		deviceWidth = 15
+	}
+	return fmt.Sprintf("%-*s   %-7s   %-23s %-23s %-23s\n",
+		deviceWidth, row.Path,
+		row.BlockSize,
+		units.Rate(row.ReadBytesPerSecond)+"("+formatIOPS(int(row.ReadIOPS), "int")+")",
+		units.Rate(row.WriteBytesPerSecond)+"("+formatIOPS(int(row.WriteIOPS), "int")+")",
+		units.Rate(row.TotalBytesPerSecond)+"("+formatIOPS(int(row.TotalIOPS), "int")+")")
+}
@@ -0,0 +1,128 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FioBinaryEnv names the environment variable holding an explicit fio
+// binary. It is used when TestOptions.FioPath or MatrixConfig.FioPath is
+// empty, and replaces both the system and the embedded fio.
+const FioBinaryEnv = "DISKTEST_FIO"
+
+// FioCapabilities lists optional fio features as derived from the fio
+// version. All are false when the version could not be parsed.
+type FioCapabilities struct {
+	IOUring     bool `json:"io_uring"`
+	SteadyState bool `json:"steadystate"`
+	JSONPlus    bool `json:"json_plus"`
+}
+
+// FioInfo describes the fio binary a test ran with. Source is explicit,
+// system or embedded; Version is the first line of fio --version.
+type FioInfo struct {
+	Path         string          `json:"path"`
+	Source       string          `json:"source"`
+	Version      string          `json:"version,omitempty"`
+	Capabilities FioCapabilities `json:"capabilities"`
+}
+
+var fioVersionPattern = regexp.MustCompile(`fio-([0-9]+)\.([0-9]+)`)
+
+// ParseFioVersion extracts the major and minor release from fio --version
+// output such as "fio-3.36" or "fio-3.28-54-g1b2c3d4".
+func ParseFioVersion(output string) (major, minor int, ok bool) {
+	match := fioVersionPattern.FindStringSubmatch(output)
+	if match == nil {
+		return 0, 0, false
+	}
+	major, _ = strconv.Atoi(match[1])
+	minor, _ = strconv.Atoi(match[2])
+	return major, minor, true
+}
+
+// fioCapabilitiesFor io_uring引擎自fio 3.13起提供，steadystate自2.20起，json+输出自2.15起
+func fioCapabilitiesFor(major, minor int) FioCapabilities {
+	atLeast := func(wantMajor, wantMinor int) bool {
+		return major > wantMajor || (major == wantMajor && minor >= wantMinor)
+	}
+	return FioCapabilities{IOUring: atLeast(3, 13), SteadyState: atLeast(2, 20), JSONPlus: atLeast(2, 15)}
+}
+
+// mayUseIOUring 只有确认版本低于3.13时才排除io_uring，无法识别的版本交给引擎探测决定
+func (info FioInfo) mayUseIOUring() bool {
+	if _, _, ok := ParseFioVersion(info.Version); !ok {
+		return true
+	}
+	return info.Capabilities.IOUring
+}
+
+// explicitFioPath 配置的fio路径优先，其次为DISKTEST_FIO环境变量
+func explicitFioPath(configured string) string {
+	if configured = strings.TrimSpace(configured); configured != "" {
+		return configured
+	}
+	return strings.TrimSpace(os.Getenv(FioBinaryEnv))
+}
+
+// inspectFio 运行fio --version，同时确认该fio可以执行
+func inspectFio(ctx context.Context, command []string, info FioInfo) (FioInfo, error) {
+	output, err := runFIOCommand(ctx, append(append([]string(nil), command...), "--version"))
+	if err != nil {
+		return info, err
+	}
+	info.Version, _, _ = strings.Cut(strings.TrimSpace(string(output)), "\n")
+	info.Version = strings.TrimSpace(info.Version)
+	if major, minor, ok := ParseFioVersion(info.Version); ok {
+		info.Capabilities = fioCapabilitiesFor(major, minor)
+	}
+	return info, nil
+}
+
+// findExplicitFIO 使用指定的fio，不可用时直接报错而不改用其他fio
+func findExplicitFIO(ctx context.Context, path string) (fioAcquisition, error) {
+	if err := ctx.Err(); err != nil {
+		return fioAcquisition{}, err
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return fioAcquisition{}, fmt.Errorf("fio binary %s is unavailable: %w", path, err)
+	}
+	info, err := inspectFio(ctx, []string{resolved}, FioInfo{Path: resolved, Source: "explicit"})
+	if err != nil {
+		if ctx.Err() != nil {
+			return fioAcquisition{}, ctx.Err()
+		}
+		return fioAcquisition{}, fmt.Errorf("fio binary %s probe failed: %w", path, err)
+	}
+	return fioAcquisition{Command: []string{resolved}, Info: info}, nil
+}
+
+// matrixFioProvider 结构化测试：指定的fio，否则系统fio，最后内置fio
+func matrixFioProvider(configured string) fioProvider {
+	if path := explicitFioPath(configured); path != "" {
+		return func(ctx context.Context) (fioAcquisition, error) { return findExplicitFIO(ctx, path) }
+	}
+	return findFIO
+}
+
+// acquireLegacyFio 传统测试：指定的fio，否则沿用内置fio
+func acquireLegacyFio(ctx context.Context, configured string) (fioAcquisition, error) {
+	if path := explicitFioPath(configured); path != "" {
+		return findExplicitFIO(ctx, path)
+	}
+	return findEmbeddedFIO(ctx)
+}
+
+// renderFioInfo 传统表格末尾显示所用fio的版本与来源
+func renderFioInfo(language string, info *FioInfo) string {
+	if info == nil || info.Version == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s (%s)\n", localizedText(language, "FIO版本", "FIO version"), info.Version, info.Source)
+}
@@ -0,0 +1,103 @@
+package disk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func writeFioScript(t *testing.T, version string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script fixture")
+	}
+	script := filepath.Join(t.TempDir(), "my-fio")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho "+version+"\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return script
+}
+
+func TestParseFioVersionCapabilities(t *testing.T) {
+	cases := []struct {
+		output string
+		want   FioCapabilities
+	}{
+		{"fio-3.36\n", FioCapabilities{IOUring: true, SteadyState: true, JSONPlus: true}},
+		{"fio-3.12-54-g1b2c3d4", FioCapabilities{SteadyState: true, JSONPlus: true}},
+		{"fio-2.16", FioCapabilities{JSONPlus: true}},
+		{"fio-2.2.10", FioCapabilities{}},
+	}
+	for _, tc := range cases {
+		major, minor, ok := ParseFioVersion(tc.output)
+		if !ok || fioCapabilitiesFor(major, minor) != tc.want {
+			t.Fatalf("%q: %d.%d %v -> %+v", tc.output, major, minor, ok, fioCapabilitiesFor(major, minor))
+		}
+	}
+	if _, _, ok := ParseFioVersion("custom build"); ok {
+		t.Fatal("unrecognized version was parsed")
+	}
+}
+
+func TestExplicitFioFromEnvironmentReplacesEmbedded(t *testing.T) {
+	script := writeFioScript(t, "fio-3.36")
+	t.Setenv(FioBinaryEnv, script)
+	originalGet := getEmbeddedFIO
+	getEmbeddedFIO = func() (string, string, error) {
+		t.Fatal("embedded fio was extracted")
+		return "", "", nil
+	}
+	defer func() { getEmbeddedFIO = originalGet }()
+	acquired, err := acquireLegacyFio(context.Background(), "")
+	if err != nil || acquired.Cleanup != nil || acquired.Command[0] != script {
+		t.Fatalf("unexpected acquisition %+v, %v", acquired, err)
+	}
+	if info := acquired.Info; info.Source != "explicit" || info.Version != "fio-3.36" || !info.Capabilities.IOUring {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	if _, err := matrixFioProvider(filepath.Join(t.TempDir(), "missing-fio"))(context.Background()); err == nil {
+		t.Fatal("missing explicit fio fell back to another binary")
+	}
+	if text := renderFioInfo("en", &acquired.Info); text != "FIO version: fio-3.36 (explicit)\n" {
+		t.Fatalf("rendered %q", text)
+	}
+}
+
+func TestMatrixGatesIOUringOnFioVersion(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("io_uring is only probed on Linux")
+	}
+	scenario := StandardFioScenarios()[0]
+	probed := func(version string, capabilities FioCapabilities) bool {
+		var engines []string
+		provider := func(context.Context) (fioAcquisition, error) {
+			return fioAcquisition{Command: []string{"fixture-fio"}, Info: FioInfo{Path: "fixture-fio", Source: "explicit", Version: version, Capabilities: capabilities}}, nil
+		}
+		runner := func(ctx context.Context, command []string) ([]byte, error) {
+			if commandArgument(command, "--name=") == "engine-check" {
+				engines = append(engines, commandArgument(command, "--ioengine="))
+				return nil, nil
+			}
+			return []byte(`{"jobs":[{"read":{"bw_bytes":1048576,"iops":256,"clat_ns":{"percentile":{"50.000000":1000}}}}]}`), nil
+		}
+		config := MatrixConfig{Path: t.TempDir(), SizeBytes: 16 << 20, Runtime: time.Second, MaxDuration: 5 * time.Second}
+		result := runFioMatrixWithDeps(context.Background(), config, []FioScenario{scenario}, time.Minute, provider, runner)
+		if result.Status != "ok" || result.Fio == nil || result.Fio.Version != version {
+			t.Fatalf("unexpected result %+v", result)
+		}
+		return len(engines) > 0 && engines[0] == "io_uring"
+	}
+	if probed("fio-3.12", fioCapabilitiesFor(3, 12)) {
+		t.Fatal("io_uring probed on a fio known to lack it")
+	}
+	if !probed("fio-3.36", fioCapabilitiesFor(3, 36)) {
+		t.Fatal("io_uring not probed on a supporting fio")
+	}
+	// 无法识别的版本不能排除io_uring，由引擎探测决定
+	if !probed("custom build", FioCapabilities{}) {
+		t.Fatal("io_uring skipped for an unparsable fio version")
+	}
+}
@@ -21,22 +21,25 @@ func TestRenderLegacyResultsKeepsPartialBlocksAndUsesOneHeader(t *testing.T) {
 	}
 }
 
-func TestProcessFioOutputParsesTerseOutputFromEitherStream(t *testing.T) {
+func TestParseFioTerseRendersRowFromEitherStream(t *testing.T) {
 	fields := make([]string, 49)
 	fields[0] = "rand_rw_4k"
 	fields[6] = "1024"
 	fields[7] = "100"
 	fields[47] = "2048"
 	fields[48] = "200"
-	got := processFioOutput(strings.Join(fields, ";"), "4k", "/")
-	if got == "" || !strings.Contains(got, "/                 4k") {
+	rows := parseFioTerse(strings.Join(fields, ";"), "4k", "/")
+	if len(rows) != 1 || rows[0].ReadBytesPerSecond != 1024*1024 || rows[0].TotalIOPS != 300 {
+		t.Fatalf("terse fio output was not parsed: %+v", rows)
+	}
+	if got := renderFioRow(rows[0], DefaultUnitFormat()); !strings.HasPrefix(got, "/                 4k") || !strings.Contains(got, "(300)") {
 		t.Fatalf("terse fio output was not rendered: %q", got)
 	}
 }
 
-func TestProcessFioOutputRejectsNonResultOutput(t *testing.T) {
-	if got := processFioOutput("fio warning: no terse result", "4k", "/"); got != "" {
-		t.Fatalf("unexpected row from non-result output: %q", got)
+func TestParseFioTerseRejectsNonResultOutput(t *testing.T) {
+	if rows := parseFioTerse("fio warning: no terse result", "4k", "/"); len(rows) != 0 {
+		t.Fatalf("unexpected row from non-result output: %+v", rows)
 	}
 }
 
@@ -76,7 +79,7 @@ func TestParseResultDDEnglishGNUAndBusyBoxOutput(t *testing.T) {
 	}
 	for _, fixture := range fixtures {
 		got := parseResultDD(fixture, "25600")
-		if !strings.Contains(got, "2.1 GB/s") || !strings.Contains(got, "IOPS") {
+		if !strings.Contains(got, "2.10 GB/s") || !strings.Contains(got, "IOPS") {
 			t.Fatalf("English dd output was not parsed: input=%q output=%q", fixture, got)
 		}
 	}
@@ -88,3 +91,58 @@ func TestParseResultDDBSDOutput(t *testing.T) {
 		t.Fatalf("BSD dd output was not parsed: %q", got)
 	}
 }
+
+func TestParseFioTerseReturnsBytesAndIOPS(t *testing.T) {
+	fields := make([]string, 49)
+	fields[0] = "rand_rw_64k"
+	fields[6] = "1024"
+	fields[7] = "16"
+	fields[47] = "2048"
+	fields[48] = "32"
+	rows := parseFioTerse(strings.Join(fields, ";"), "64k", "/data")
+	if len(rows) != 1 {
+		t.Fatalf("rows = %+v", rows)
+	}
+	row := rows[0]
+	if row.Path != "/data" || row.ReadBytesPerSecond != 1024*1024 || row.TotalBytesPerSecond != 3072*1024 || row.TotalIOPS != 48 {
+		t.Fatalf("unexpected typed row: %+v", row)
+	}
+	if rendered := RenderFioLegacy("en", FioLegacyResult{Results: rows}); !strings.Contains(rendered, "3.15 MB/s(48)") {
+		t.Fatalf("typed row rendered as %q", rendered)
+	}
+}
+
+func TestRenderFioLegacyReportsUncreatablePath(t *testing.T) {
+	got := RenderFioLegacy("en", FioLegacyResult{Status: "unavailable", Error: "test_path_create_failed"})
+	if got != "Unable to create test path\n" {
+		t.Fatalf("rendered = %q", got)
+	}
+}
+
+func TestParseDDMeasurementReturnsNumbers(t *testing.T) {
+	measurement, ok := parseDDMeasurement("104857600 bytes (105 MB, 100 MiB) copied, 4.0 s, 26.2 MB/s", "25600")
+	if !ok || measurement.Bytes != 104857600 || measurement.Seconds != 4 || measurement.BytesPerSecond != 104857600/4 || measurement.IOPS != 6400 {
+		t.Fatalf("unexpected GNU measurement: %+v ok=%v", measurement, ok)
+	}
+	measurement, ok = parseDDMeasurement("104857600 bytes transferred in 0.050 secs (2097152000 bytes/sec)", "25600")
+	if !ok || measurement.Bytes != 104857600 || measurement.BytesPerSecond != 2097152000 || measurement.ReportedSpeed != "" {
+		t.Fatalf("unexpected BSD measurement: %+v ok=%v", measurement, ok)
+	}
+	if _, ok := parseDDMeasurement("dd: error writing: No space left on device", "25600"); ok {
+		t.Fatal("error output parsed as a measurement")
+	}
+}
+
+func TestRenderDDLegacyFromTypedResult(t *testing.T) {
+	measurement, _ := parseDDMeasurement("104857600 bytes (105 MB, 100 MiB) copied, 4.0 s, 26.2 MB/s", "25600")
+	result := DDLegacyResult{Results: []DDResult{
+		{Path: "/root", Device: "/root", BlockName: "100MB-4K Block", WriteStatus: "ok", Write: measurement, ReadStatus: "failed", FailureReason: "read_failed"},
+		{Path: "/data", Device: "/data", BlockName: "1GB-1M Block", WriteStatus: "ok", Write: measurement, ReadStatus: "ok", Read: measurement, ReadMode: "cache-dropped"},
+		{Path: "/tmp", Device: "/tmp", BlockName: "1GB-1M Block", FailureReason: "write_source_unavailable"},
+	}}
+	got := RenderDDLegacy("en", result)
+	if !strings.Contains(got, "26.21 MB/s(6.40K IOPS, 4.00s)") || !strings.Contains(got, "Read failed") || strings.Contains(got, "/tmp") ||
+		!strings.Contains(got, "Read Mode") || !strings.Contains(got, "cache dropped\n") {
+		t.Fatalf("unexpected DD table: %q", got)
+	}
+}
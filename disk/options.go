@@ -0,0 +1,210 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// TestOptions configures a legacy benchmark run. Zero values keep the
+// behavior of the positional FioTest/DDTest/WinsatTest API, which are thin
+// wrappers around RunTest.
+type TestOptions struct {
+	Context context.Context
+	// Paths lists explicit test directories. When empty, the default path
+	// (or every discovered mount with MultiCheck) is tested.
+	Paths      []string
+	MultiCheck bool
+	// Method is fio, dd or winsat; empty selects fio.
+	Method   string
+	Language string
+	// FioSize is the FIO test-file size such as "2G"; empty picks 2G, or
+	// 512M on ARM. It is still shrunk when free space is short.
+	FioSize string
+	// FioRuntime, FioBlockSizes, FioIODepth and FioNumJobs shape each table
+	// row; zero values keep 30s, 4k/64k/512k/1m, depth 64 and 2 jobs.
+	FioRuntime    time.Duration
+	FioBlockSizes []string
+	FioIODepth    int
+	FioNumJobs    int
+	// DDTests lists the DD block/total size pairs; empty keeps 100MB-4K and
+	// 1GB-1M.
+	DDTests []DDTestSpec
+	// DDDropCaches lets a root run flush the whole page cache through
+	// /proc/sys/vm/drop_caches when the DD read cannot use iflag=direct.
+	DDDropCaches bool
+	// DataPattern selects the written data for both methods; the zero value
+	// keeps /dev/zero for dd and fio's default buffers.
+	DataPattern DataPattern
+	// ScoreTable replaces DefaultLegacyScoreTable when scoring FIO results.
+	ScoreTable *ScoreTable
+	// DiscoveryFilter adjusts which mounts MultiCheck tests with fio and dd.
+	DiscoveryFilter DiscoveryFilter
+	// Units selects SI or IEC units and their precision for the rendered
+	// tables; the zero value keeps SI with two decimals.
+	Units UnitFormat
+	// DryRun resolves the paths, sizes and commands into TestResult.Plan
+	// without writing files or extracting binaries.
+	DryRun bool
+	// FioPath selects the fio binary instead of the embedded one; empty
+	// falls back to the DISKTEST_FIO environment variable.
+	FioPath string
+	// Fallback lists the methods tried in order when Method is unavailable
+	// on this host; nil disables fallback. See DefaultFallback.
+	Fallback []string
+	// Output, when set, receives the rendered table as well.
+	Output io.Writer
+}
+
+// TestResult holds the typed result of the selected method together with
+// the rendered localized table.
+type TestResult struct {
+	Method string `json:"method"`
+	Status string `json:"status"`
+	// Unavailable lists the methods that were tried and could not run,
+	// in the order they were tried.
+	Unavailable []string         `json:"unavailable,omitempty"`
+	Fio         *FioLegacyResult `json:"fio,omitempty"`
+	DD          *DDLegacyResult  `json:"dd,omitempty"`
+	Plan        *TestPlan        `json:"plan,omitempty"`
+	// Error is a stable code such as invalid_score_table when the options
+	// were rejected before any method ran.
+	Error string `json:"error,omitempty"`
+	Text  string `json:"-"`
+}
+
+// validateTestOptions 校验选项，失败时返回稳定的错误码与本地化说明
+func validateTestOptions(opts TestOptions) (string, string) {
+	var scoreTableErr error
+	if opts.ScoreTable != nil {
+		scoreTableErr = opts.ScoreTable.ValidateLegacy()
+	}
+	for _, check := range []struct {
+		code, zh, en string
+		err          error
+	}{
+		{"invalid_data_pattern", "无效的数据模式", "Invalid data pattern", opts.DataPattern.validate()},
+		{"invalid_score_table", "无效的评分表", "Invalid score table", scoreTableErr},
+		{"invalid_discovery_filter", "无效的挂载点过滤条件", "Invalid discovery filter", opts.DiscoveryFilter.Validate()},
+		{"invalid_unit_format", "无效的单位格式", "Invalid unit format", opts.Units.Validate()},
+	} {
+		if check.err != nil {
+			return check.code, fmt.Sprintf("%s: %v.\n", localizedText(opts.Language, check.zh, check.en), check.err)
+		}
+	}
+	return "", ""
+}
+
+// RunTest runs one legacy benchmark described by opts.
+func RunTest(opts TestOptions) TestResult {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	method := strings.ToLower(strings.TrimSpace(opts.Method))
+	if method == "" {
+		method = "fio"
+	}
+	paths := make([]string, 0, len(opts.Paths))
+	for _, path := range opts.Paths {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	result := TestResult{Method: method}
+	if code, text := validateTestOptions(opts); code != "" {
+		result.Status, result.Error, result.Text = "unavailable", code, text
+		if opts.Output != nil {
+			io.WriteString(opts.Output, result.Text)
+		}
+		return result
+	}
+	if opts.DryRun {
+		backend, exists := LookupBackend(method)
+		planner, ok := backend.(Planner)
+		if !exists || !ok {
+			result.Status = "unavailable"
+			result.Text = fmt.Sprintf("Dry run is not supported by test method %q.\n", method)
+		} else {
+			plan := planner.Plan(opts, paths)
+			result.Status = "planned"
+			result.Plan = &plan
+			result.Text = RenderTestPlan(opts.Language, plan)
+		}
+		if opts.Output != nil {
+			io.WriteString(opts.Output, result.Text)
+		}
+		return result
+	}
+	result = runBackends(ctx, opts, backendChain(method, opts.Fallback), paths)
+	if opts.Output != nil && result.Text != "" {
+		io.WriteString(opts.Output, result.Text)
+	}
+	return result
+}
+
+func runFioLegacyPaths(ctx context.Context, config fioLegacyConfig, multiCheck bool, paths []string) FioLegacyResult {
+	if len(paths) == 0 {
+		return runFioLegacy(ctx, config, multiCheck, "")
+	}
+	merged := FioLegacyResult{DataPattern: config.pattern.String(), Units: config.units.resolved()}
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		current := runFioLegacy(ctx, config, false, path)
+		merged.Results = append(merged.Results, current.Results...)
+		merged.Errors = append(merged.Errors, current.Errors...)
+		merged.Stacks = mergePathMaps(merged.Stacks, current.Stacks)
+		merged.Throttles = mergePathMaps(merged.Throttles, current.Throttles)
+		merged.Scores = mergePathMaps(merged.Scores, current.Scores)
+		if merged.Fio == nil {
+			merged.Fio = current.Fio
+		}
+		if merged.Error == "" {
+			merged.Error = current.Error
+		}
+	}
+	merged.Status = legacyResultStatus(len(merged.Results), len(merged.Errors))
+	if ctx.Err() != nil {
+		merged.Status, merged.Error = matrixStopStatus(ctx.Err()), stableMatrixError(ctx.Err())
+	}
+	return merged
+}
+
+func runDDLegacyPaths(ctx context.Context, config ddLegacyConfig, multiCheck bool, paths []string) DDLegacyResult {
+	if len(paths) == 0 {
+		return runDDLegacy(ctx, config, multiCheck, "")
+	}
+	merged := DDLegacyResult{Units: config.units.resolved()}
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			break
+		}
+		current := runDDLegacy(ctx, config, false, path)
+		merged.Results = append(merged.Results, current.Results...)
+		merged.Stacks = mergePathMaps(merged.Stacks, current.Stacks)
+		merged.Throttles = mergePathMaps(merged.Throttles, current.Throttles)
+		if merged.Error == "" {
+			merged.Error = current.Error
+		}
+	}
+	merged.Status = ddLegacyStatus(merged.Results)
+	if ctx.Err() != nil {
+		merged.Status, merged.Error = matrixStopStatus(ctx.Err()), stableMatrixError(ctx.Err())
+	}
+	return merged
+}
+
+// mergePathMaps 合并按路径索引的附加信息
+func mergePathMaps[T any](merged, current map[string]T) map[string]T {
+	for path, value := range current {
+		if merged == nil {
+			merged = make(map[string]T)
+		}
+		merged[path] = value
+	}
+	return merged
+}
@@ -0,0 +1,156 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+func TestRunTestWritesToOutputSink(t *testing.T) {
+	var output bytes.Buffer
+	result := RunTest(TestOptions{Method: "unknown", Output: &output})
+	if result.Method != "unknown" || output.String() != result.Text || result.Text == "" {
+		t.Fatalf("unexpected result %+v output=%q", result, output.String())
+	}
+}
+
+func TestRunTestRejectsInvalidOptionsWithStableCodes(t *testing.T) {
+	for code, opts := range map[string]TestOptions{
+		"invalid_data_pattern":     {DataPattern: DataPattern{Kind: "bogus"}},
+		"invalid_score_table":      {ScoreTable: &ScoreTable{}},
+		"invalid_discovery_filter": {DiscoveryFilter: DiscoveryFilter{IncludeDevice: "["}},
+		"invalid_unit_format":      {Units: UnitFormat{System: "bogus"}},
+	} {
+		var output bytes.Buffer
+		opts.Language, opts.Output = "en", &output
+		result := RunTest(opts)
+		if result.Status != "unavailable" || result.Error != code || !strings.HasPrefix(result.Text, "Invalid ") || output.String() != result.Text {
+			t.Fatalf("unexpected result for %s: %+v", code, result)
+		}
+		opts.Language, opts.Output = "zh", nil
+		if result := RunTest(opts); result.Error != code || !strings.HasPrefix(result.Text, "无效") {
+			t.Fatalf("unlocalized result for %s: %+v", code, result)
+		}
+	}
+}
+
+func TestRunTestStopsCanceledDDForEveryPath(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := RunTest(TestOptions{Context: ctx, Method: "DD", Paths: []string{t.TempDir(), " ", t.TempDir()}})
+	if result.DD == nil || result.DD.Status != "canceled" || len(result.DD.Results) != 0 || result.Text != "" {
+		t.Fatalf("unexpected canceled run: %+v", result)
+	}
+}
+
+func TestFioTestContextReportsCancellationWithoutLeftovers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	directory := t.TempDir()
+	result := RunTest(TestOptions{Context: ctx, Method: "fio", Language: "en", Paths: []string{directory}})
+	if result.Fio == nil || result.Fio.Status != "canceled" || result.Fio.Error != "canceled" || result.Text != "FIO test canceled\n" {
+		t.Fatalf("unexpected canceled run: %+v", result)
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 0 {
+		t.Fatalf("canceled run left files behind: %v", entries)
+	}
+}
+
+func TestRenderFioLegacyKeepsRowsBeforeTimeout(t *testing.T) {
+	text := RenderFioLegacy("en", FioLegacyResult{Status: "timeout", Error: "timeout", Results: []FioBlockResult{{Path: "/data", BlockSize: "4k", ReadIOPS: 10}}})
+	if !strings.Contains(text, "/data") || !strings.HasSuffix(text, "FIO test timed out\n") {
+		t.Fatalf("unexpected timeout rendering:\n%s", text)
+	}
+}
+
+func TestParseSizeBytes(t *testing.T) {
+	for input, want := range map[string]uint64{"512M": 512 << 20, "2G": 2 << 30, "1.5GiB": 3 << 29, "4k": 4096, "100": 100} {
+		if got, err := parseSizeBytes(input); err != nil || got != want {
+			t.Fatalf("parseSizeBytes(%q) = %d, %v; want %d", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"", "-1G", "lots"} {
+		if _, err := parseSizeBytes(input); err == nil {
+			t.Fatalf("parseSizeBytes(%q) accepted invalid input", input)
+		}
+	}
+}
+
+func TestFioLegacyConfigDefaults(t *testing.T) {
+	config := fioLegacyConfig{runtime: 5 * time.Second, numJobs: 1}.withDefaults()
+	if config.runtime != 5*time.Second || config.numJobs != 1 || config.ioDepth != 64 || strings.Join(config.blockSizes, ",") != "4k,64k,512k,1m" || config.size == "" {
+		t.Fatalf("unexpected defaults: %+v", config)
+	}
+}
+
+func TestDDTestPlansKeepLegacyNames(t *testing.T) {
+	var got []string
+	for _, spec := range defaultDDTests {
+		plan, err := newDDTestPlan(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, plan.name()+"|"+plan.file()+"|"+plan.count())
+	}
+	if strings.Join(got, ",") != "100MB-4K Block|100MB.test|25600,1GB-1M Block|1GB.test|1000" {
+		t.Fatalf("default dd plans changed: %v", got)
+	}
+	// 不是整MB的总大小按实际字节显示，不截断为整MB
+	for spec, want := range map[DDTestSpec]string{{BlockSize: "4k", TotalSize: "1500K"}: "1500KB-4K Block|1500KB.test|375", {BlockSize: "512", TotalSize: "1049088"}: "1049088B-512 Block|1049088B.test|2049"} {
+		plan, err := newDDTestPlan(spec)
+		if err != nil || plan.name()+"|"+plan.file()+"|"+plan.count() != want {
+			t.Fatalf("newDDTestPlan(%+v) = %s|%s|%s, %v", spec, plan.name(), plan.file(), plan.count(), err)
+		}
+	}
+	specs, err := ParseDDTests(" 64k:256M, 4M:2G ")
+	if err != nil || len(specs) != 2 || specs[1] != (DDTestSpec{BlockSize: "4M", TotalSize: "2G"}) {
+		t.Fatalf("ParseDDTests = %+v, %v", specs, err)
+	}
+	for _, input := range []string{"", "4k", "4KiB:100M", "1M:512K", "8M:4M", "4k:1000001", "3M:10M"} {
+		if _, err := ParseDDTests(input); err == nil {
+			t.Fatalf("ParseDDTests(%q) accepted invalid input", input)
+		}
+	}
+}
+
+func TestAdjustDDTestSizeShrinksAnyPair(t *testing.T) {
+	plan, err := newDDTestPlan(DDTestSpec{BlockSize: "64k", TotalSize: "1000000G"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adjusted := adjustDDTestSize(t.TempDir(), []ddTestPlan{plan}, DataPattern{})[0]
+	if adjusted.totalBytes >= plan.totalBytes || adjusted.totalBytes%(1<<20) != 0 || !strings.HasSuffix(adjusted.name(), "-64K Block") {
+		t.Fatalf("oversized plan was not shrunk: %+v %s", adjusted, adjusted.name())
+	}
+}
+
+func TestAdjustDDTestSizeCountsPatternSource(t *testing.T) {
+	directory := t.TempDir()
+	usage, err := disk.Usage(directory)
+	if err != nil || usage.Free < 64<<20 {
+		t.Skip("free space unavailable")
+	}
+	// 测试文件占可用空间的一半：单独写入足够，加上同样大小的数据源文件则不够
+	plan, err := newDDTestPlan(DDTestSpec{BlockSize: "1M", TotalSize: fmt.Sprintf("%dM", usage.Free/2>>20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zeros := adjustDDTestSize(directory, []ddTestPlan{plan}, DataPattern{})[0]; runtime.GOOS != "windows" && zeros.totalBytes != plan.totalBytes {
+		t.Fatalf("/dev/zero source shrank the plan to %d", zeros.totalBytes)
+	}
+	random := adjustDDTestSize(directory, []ddTestPlan{plan}, DataPattern{Kind: "random"})[0]
+	if random.totalBytes*2 > usage.Free/2 {
+		t.Fatalf("test and source files need %d bytes of %d free", random.totalBytes*2, usage.Free)
+	}
+	planned := RunTest(TestOptions{Method: "dd", Paths: []string{directory}, DryRun: true, DDTests: []DDTestSpec{{BlockSize: "1M", TotalSize: fmt.Sprintf("%dM", usage.Free/2>>20)}}, DataPattern: DataPattern{Kind: "random"}})
+	if planned.Plan == nil || planned.Plan.PeakSpaceBytes != random.totalBytes*2 {
+		t.Fatalf("planner disagrees with sizing: %+v", planned.Plan)
+	}
+}
@@ -0,0 +1,92 @@
+package disk
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DataPattern selects the data written by the DD and FIO benchmarks, so
+// compressing or deduplicating storage cannot inflate write speeds. The zero
+// value keeps the historical data: /dev/zero for dd and fio's own buffers.
+type DataPattern struct {
+	// Kind is zeros, random or compressible.
+	Kind string `json:"kind"`
+	// CompressPercent is how compressible each buffer is for the
+	// compressible kind, from 1 to 99.
+	CompressPercent int `json:"compress_percent,omitempty"`
+}
+
+// ParseDataPattern parses "zeros", "random" or "compressible:N" where N is
+// the fio buffer_compress_percentage.
+func ParseDataPattern(value string) (DataPattern, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	kind, percent, hasPercent := strings.Cut(value, ":")
+	pattern := DataPattern{Kind: kind}
+	if hasPercent {
+		parsed, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(percent), "%"))
+		if err != nil {
+			return DataPattern{}, fmt.Errorf("invalid compress percentage %q", percent)
+		}
+		pattern.CompressPercent = parsed
+	}
+	if err := pattern.validate(); err != nil {
+		return DataPattern{}, err
+	}
+	return pattern, nil
+}
+
+func (pattern DataPattern) validate() error {
+	switch pattern.Kind {
+	case "", "zeros", "random":
+		if pattern.CompressPercent != 0 {
+			return fmt.Errorf("compress percentage is only used with the compressible pattern")
+		}
+	case "compressible":
+		if pattern.CompressPercent < 1 || pattern.CompressPercent > 99 {
+			return fmt.Errorf("compress percentage must be between 1 and 99")
+		}
+	default:
+		return fmt.Errorf("data pattern must be zeros, random or compressible:N")
+	}
+	return nil
+}
+
+// String returns the pattern in ParseDataPattern form, or "" for the zero value.
+func (pattern DataPattern) String() string {
+	if pattern.Kind == "compressible" {
+		return fmt.Sprintf("compressible:%d", pattern.CompressPercent)
+	}
+	return pattern.Kind
+}
+
+// fioArgs 返回对应数据模式的fio缓冲区参数
+func (pattern DataPattern) fioArgs() []string {
+	switch pattern.Kind {
+	case "zeros":
+		return []string{"--zero_buffers"}
+	case "random":
+		return []string{"--refill_buffers"}
+	case "compressible":
+		return []string{"--refill_buffers", "--buffer_compress_percentage=" + strconv.Itoa(pattern.CompressPercent)}
+	}
+	return nil
+}
+
+// fillPatternBlock 按数据模式填充一个块：压缩模式下块的前N%为零，其余为随机数据
+func fillPatternBlock(block []byte, pattern DataPattern) error {
+	switch pattern.Kind {
+	case "random":
+		_, err := rand.Read(block)
+		return err
+	case "compressible":
+		zeros := len(block) * pattern.CompressPercent / 100
+		clear(block[:zeros])
+		_, err := rand.Read(block[zeros:])
+		return err
+	default:
+		clear(block)
+		return nil
+	}
+}
@@ -0,0 +1,66 @@
+package disk
+
+import (
+	"bytes"
+	"compress/flate"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseDataPattern(t *testing.T) {
+	for input, want := range map[string]DataPattern{
+		"zeros":            {Kind: "zeros"},
+		" Random ":         {Kind: "random"},
+		"compressible:25%": {Kind: "compressible", CompressPercent: 25},
+	} {
+		got, err := ParseDataPattern(input)
+		if err != nil || got != want {
+			t.Fatalf("ParseDataPattern(%q) = %+v, %v", input, got, err)
+		}
+	}
+	for _, input := range []string{"ones", "random:10", "compressible", "compressible:0", "compressible:x"} {
+		if _, err := ParseDataPattern(input); err == nil {
+			t.Fatalf("ParseDataPattern(%q) accepted invalid input", input)
+		}
+	}
+	if args := strings.Join((DataPattern{Kind: "compressible", CompressPercent: 60}).fioArgs(), " "); args != "--refill_buffers --buffer_compress_percentage=60" {
+		t.Fatalf("unexpected fio args %q", args)
+	}
+}
+
+func TestCreatePatternFileIsIncompressible(t *testing.T) {
+	directory := t.TempDir()
+	compressedSize := func(pattern DataPattern) int {
+		source, cleanup, err := prepareDDWriteSource(directory, "64k", "16", pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer cleanup()
+		data, err := os.ReadFile(source)
+		if err != nil || len(data) != 1<<20 {
+			t.Fatalf("pattern file has %d bytes, err=%v", len(data), err)
+		}
+		var compressed bytes.Buffer
+		writer, _ := flate.NewWriter(&compressed, flate.BestSpeed)
+		writer.Write(data)
+		writer.Close()
+		return compressed.Len()
+	}
+	random := compressedSize(DataPattern{Kind: "random"})
+	half := compressedSize(DataPattern{Kind: "compressible", CompressPercent: 50})
+	if random < 1<<20 || half > 6<<17 || half < 1<<18 {
+		t.Fatalf("unexpected compressed sizes random=%d half=%d", random, half)
+	}
+	if entries, _ := os.ReadDir(directory); len(entries) != 0 {
+		t.Fatalf("pattern source left behind: %v", entries)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if source, _, _ := prepareDDWriteSource(filepath.Join(directory, "unused"), "4k", "1", DataPattern{}); source != getDevZeroPath() {
+		t.Fatalf("default pattern should read %s, got %s", getDevZeroPath(), source)
+	}
+}
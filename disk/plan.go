@@ -0,0 +1,348 @@
+package disk
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// TestPlan is the workload a dry run resolved: the test paths, the sizes
+// after shrinking to the free space, every command a real run would start,
+// the time budget and the peak temporary disk space. Planning only reads
+// mount and usage information; it creates no files and extracts no
+// binaries.
+type TestPlan struct {
+	Method  string       `json:"method"`
+	Paths   []PathPlan   `json:"paths,omitempty"`
+	Aliases []MountAlias `json:"aliases,omitempty"`
+	// BudgetMS is the longest the planned commands may run. Steps without
+	// a time limit, such as dd transfers, are not counted.
+	BudgetMS int64 `json:"budget_ms"`
+	// PeakSpaceBytes is the most temporary data on disk at one time,
+	// summed over the paths whose files exist together.
+	PeakSpaceBytes uint64 `json:"peak_space_bytes"`
+	// Notes are stable codes for run-time decisions the plan cannot
+	// resolve without writing, such as io_engine_probed.
+	Notes []string   `json:"notes,omitempty"`
+	Error string     `json:"error,omitempty"`
+	Units UnitFormat `json:"units"`
+}
+
+// PathPlan is the planned work on one test path.
+type PathPlan struct {
+	Path      string `json:"path"`
+	FreeBytes uint64 `json:"free_bytes,omitempty"`
+	// Missing is set when the path does not exist yet; a real run creates
+	// it first.
+	Missing        bool       `json:"missing,omitempty"`
+	Steps          []PlanStep `json:"steps"`
+	PeakSpaceBytes uint64     `json:"peak_space_bytes"`
+}
+
+// PlanStep is one command of a planned run.
+type PlanStep struct {
+	Name    string   `json:"name"`
+	Command []string `json:"command"`
+	// SizeBytes is the size of the file the step writes or reads.
+	SizeBytes uint64 `json:"size_bytes,omitempty"`
+	// BudgetMS is the time limit the step runs under; zero means it runs
+	// until the command exits.
+	BudgetMS int64 `json:"budget_ms"`
+}
+
+// planIOEngine 预演时的IO引擎占位，实际引擎在运行时探测
+const planIOEngine = "<probed>"
+
+// planLegacy 解析传统测试的路径、大小与命令，不写入文件
+func planLegacy(opts TestOptions, method string, paths []string) TestPlan {
+	plan := TestPlan{Method: method, Units: opts.Units.resolved()}
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+	for _, testPath := range paths {
+		switch method {
+		case "fio":
+			config := fioLegacyConfig{size: opts.FioSize, runtime: opts.FioRuntime, blockSizes: opts.FioBlockSizes, ioDepth: opts.FioIODepth, numJobs: opts.FioNumJobs, pattern: opts.DataPattern, binary: opts.FioPath}
+			planFioLegacy(&plan, config.withDefaults(), opts.DiscoveryFilter, opts.MultiCheck, testPath)
+		case "dd":
+			planDDLegacy(&plan, ddLegacyConfig{tests: opts.DDTests, pattern: opts.DataPattern}, opts.DiscoveryFilter, opts.MultiCheck, testPath)
+		case "winsat":
+			planWinsat(&plan, opts.MultiCheck, testPath)
+		}
+		if plan.Error != "" {
+			break
+		}
+	}
+	return plan
+}
+
+// planLegacyPaths 按实际测试的规则解析测试路径，单路径模式下fio先用rootPath，dd选择可写的默认路径
+func planLegacyPaths(plan *TestPlan, filter DiscoveryFilter, multiCheck bool, testPath string) []string {
+	if testPath != "" {
+		return []string{testPath}
+	}
+	pathInfo, err := getTestPathsWith(filter, planWritable)
+	if err != nil {
+		plan.Error = "test_path_discovery_failed"
+		return nil
+	}
+	if multiCheck {
+		plan.Aliases = append(plan.Aliases, pathInfo.Aliases...)
+		return pathInfo.MountPoints
+	}
+	rootPath, tmpPath := getDefaultTestPaths()
+	defaultPath := rootPath
+	if plan.Method == "fio" {
+		plan.addNote("fio_tmp_fallback")
+	} else if runtime.GOOS == "darwin" || !planWritable(rootPath) {
+		defaultPath = tmpPath
+	}
+	paths := []string{defaultPath}
+	for _, path := range pathInfo.MountPoints {
+		if path == rootPath || path == tmpPath {
+			continue
+		}
+		if usage, err := disk.Usage(path); err == nil && usage.Free > uint64(210*1024*1024*1024) {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func planFioLegacy(plan *TestPlan, config fioLegacyConfig, filter DiscoveryFilter, multiCheck bool, testPath string) {
+	command := "fio"
+	if path := explicitFioPath(config.binary); path != "" {
+		command = path
+	} else {
+		plan.addNote("fio_embedded")
+	}
+	plan.addNote("io_engine_probed")
+	runtimeSeconds := max(int(config.runtime.Seconds()), 1)
+	for _, path := range planLegacyPaths(plan, filter, multiCheck, testPath) {
+		pathPlan := newPathPlan(path)
+		fioSize := adjustFioTestSize(path, config.size)
+		sizeBytes, _ := parseSizeBytes(fioSize)
+		testFilePath := filepath.Join(path, "test.fio")
+		pathPlan.Steps = append(pathPlan.Steps, PlanStep{Name: "setup", Command: append([]string{command}, fioSetupArgs(planIOEngine, fioSize, testFilePath)...), SizeBytes: sizeBytes})
+		for _, BS := range config.blockSizes {
+			pathPlan.Steps = append(pathPlan.Steps, PlanStep{
+				Name:      "rand_rw_" + BS,
+				Command:   append([]string{command}, legacyFioArgs(BS, planIOEngine, fioSize, testFilePath, runtimeSeconds, config)...),
+				SizeBytes: sizeBytes,
+				BudgetMS:  int64(runtimeSeconds+5) * 1000,
+			})
+		}
+		// 各路径的test.fio在整个测试结束后才删除，峰值为各路径之和
+		pathPlan.PeakSpaceBytes = sizeBytes
+		plan.PeakSpaceBytes += sizeBytes
+		plan.addPath(pathPlan)
+	}
+}
+
+func planDDLegacy(plan *TestPlan, config ddLegacyConfig, filter DiscoveryFilter, multiCheck bool, testPath string) {
+	specs := config.tests
+	if len(specs) == 0 {
+		specs = defaultDDTests
+	}
+	plans := make([]ddTestPlan, 0, len(specs))
+	for _, spec := range specs {
+		ddPlan, err := newDDTestPlan(spec)
+		if err != nil {
+			plan.Error = "invalid_dd_test"
+			return
+		}
+		plans = append(plans, ddPlan)
+	}
+	plan.addNote("dd_binary_resolved")
+	for _, path := range planLegacyPaths(plan, filter, multiCheck, testPath) {
+		pathPlan := newPathPlan(path)
+		for _, ddPlan := range adjustDDTestSize(path, plans, config.pattern) {
+			blockFile := filepath.Join(path, ddPlan.file())
+			source, sourceBytes := getDevZeroPath(), uint64(0)
+			if ddSourceOnDisk(config.pattern) {
+				source, sourceBytes = filepath.Join(path, ddSourceName(config.pattern)), ddPlan.totalBytes
+			}
+			readFlags := []string(nil)
+			if runtime.GOOS != "windows" && runtime.GOOS != "darwin" {
+				readFlags = []string{"iflag=direct"}
+				plan.addNote("dd_read_fallback")
+			}
+			pathPlan.Steps = append(pathPlan.Steps,
+				PlanStep{Name: ddPlan.name() + " write", Command: append([]string{"dd"}, ddArgs(source, blockFile, ddPlan.blockSize, ddPlan.count(), ddWriteFlags())...), SizeBytes: ddPlan.totalBytes},
+				PlanStep{Name: ddPlan.name() + " read", Command: append([]string{"dd"}, ddArgs(blockFile, getDevNullPath(), ddPlan.blockSize, ddPlan.count(), readFlags)...), SizeBytes: ddPlan.totalBytes},
+			)
+			// 每组测试结束即删除测试文件与数据源文件
+			pathPlan.PeakSpaceBytes = max(pathPlan.PeakSpaceBytes, ddPlan.totalBytes+sourceBytes)
+		}
+		plan.PeakSpaceBytes = max(plan.PeakSpaceBytes, pathPlan.PeakSpaceBytes)
+		plan.addPath(pathPlan)
+	}
+}
+
+func planWinsat(plan *TestPlan, multiCheck bool, testPath string) {
+	var drives []string
+	switch {
+	case testPath != "":
+		drives = []string{testPath}
+	case multiCheck:
+		parts, err := disk.Partitions(true)
+		if err != nil {
+			plan.Error = "test_path_discovery_failed"
+			return
+		}
+		for _, part := range parts {
+			drives = append(drives, part.Device)
+		}
+	default:
+		drives = []string{"C:"}
+	}
+	for _, drive := range drives {
+		plan.addPath(PathPlan{Path: drive, Steps: []PlanStep{{Name: "winsat", Command: []string{"winsat", "disk", "-drive", drive}}}})
+	}
+}
+
+// planMatrix 按结构化矩阵的默认值与限制生成计划，测试文件名在运行时随机生成
+func planMatrix(config MatrixConfig, scenarios []FioScenario) TestPlan {
+	plan := TestPlan{Method: "fio", Units: DefaultUnitFormat()}
+	command := "fio"
+	if path := explicitFioPath(config.FioPath); path != "" {
+		command = path
+	} else if path, err := exec.LookPath("fio"); err == nil {
+		command = path
+	} else {
+		plan.addNote("fio_embedded")
+	}
+	plan.addNote("io_engine_probed")
+	perScenarioRuntime := min(config.Runtime, config.MaxDuration/time.Duration(len(scenarios)))
+	testPath := filepath.Join(config.Path, ".goecs-fio-<random>")
+	pathPlan := newPathPlan(config.Path)
+	for _, scenario := range scenarios {
+		pathPlan.Steps = append(pathPlan.Steps, PlanStep{
+			Name:      scenario.ID,
+			Command:   append([]string{command}, matrixFioArgs(scenario, planIOEngine, testPath, perScenarioRuntime, config)...),
+			SizeBytes: uint64(config.SizeBytes),
+			BudgetMS:  max(perScenarioRuntime, time.Second).Milliseconds(),
+		})
+	}
+	// 测试文件之外还有1MiB的IO引擎探测文件
+	pathPlan.PeakSpaceBytes = uint64(config.SizeBytes) + 1<<20
+	plan.PeakSpaceBytes = pathPlan.PeakSpaceBytes
+	if err := planMatrixSpace(config.Path, config.SizeBytes); err != nil {
+		plan.Error = stableTestPathError(err)
+	}
+	plan.addPath(pathPlan)
+	// 整个矩阵受MaxDuration限制
+	plan.BudgetMS = min(plan.BudgetMS, config.MaxDuration.Milliseconds())
+	return plan
+}
+
+// planMatrixSpace 与ensureMatrixSpace相同的检查，但不创建写入探测文件
+func planMatrixSpace(path string, requested int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("fio path is not a directory")
+	}
+	if !planWritable(path) {
+		return os.ErrPermission
+	}
+	if requested < 16<<20 {
+		return fmt.Errorf("fio size must be at least 16 MiB")
+	}
+	if filepath.Clean(path) == "/dev" {
+		return fmt.Errorf("raw device paths are not allowed")
+	}
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return err
+	}
+	if usage.Free <= uint64(requested)+matrixSpaceReserve {
+		return fmt.Errorf("insufficient free space for fio test and safety reserve")
+	}
+	return nil
+}
+
+func newPathPlan(path string) PathPlan {
+	pathPlan := PathPlan{Path: path}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		pathPlan.Missing = true
+	}
+	if usage, err := disk.Usage(path); err == nil {
+		pathPlan.FreeBytes = usage.Free
+	}
+	return pathPlan
+}
+
+func (plan *TestPlan) addPath(pathPlan PathPlan) {
+	for _, step := range pathPlan.Steps {
+		plan.BudgetMS += step.BudgetMS
+	}
+	plan.Paths = append(plan.Paths, pathPlan)
+}
+
+func (plan *TestPlan) addNote(note string) {
+	for _, existing := range plan.Notes {
+		if existing == note {
+			return
+		}
+	}
+	plan.Notes = append(plan.Notes, note)
+}
+
+// planNotes 计划备注的本地化文本
+var planNotes = map[string][2]string{
+	"fio_embedded":       {"fio使用内置二进制，运行时才解压", "fio uses the embedded binary, extracted only when the test runs"},
+	"io_engine_probed":   {planIOEngine + " 为运行时探测到的IO引擎", planIOEngine + " is the I/O engine probed when the test runs"},
+	"fio_tmp_fallback":   {"默认路径生成测试文件失败时改用临时目录", "if the test file cannot be created in the default path, the temporary directory is used"},
+	"dd_binary_resolved": {"dd优先使用系统命令，否则运行时解压内置二进制", "dd prefers the system command and extracts the embedded binary only when needed"},
+	"dd_read_fallback":   {"不支持iflag=direct时，清除页缓存后普通读取", "without iflag=direct support, the read drops the page cache and reads normally"},
+}
+
+// RenderTestPlan renders a dry-run plan as localized text.
+func RenderTestPlan(language string, plan TestPlan) string {
+	var text strings.Builder
+	text.WriteString(localizedText(language, "预演模式：不创建任何文件，也不解压任何二进制", "Dry run: no files are created and no binaries are extracted") + "\n")
+	text.WriteString(localizedText(language, "测试方式: ", "Method: ") + plan.Method + "\n")
+	for _, pathPlan := range plan.Paths {
+		line := localizedText(language, "测试路径: ", "Test path: ") + pathPlan.Path
+		if pathPlan.FreeBytes > 0 {
+			line += localizedText(language, "    可用空间: ", "    Free: ") + plan.Units.Bytes(float64(pathPlan.FreeBytes))
+		}
+		if pathPlan.PeakSpaceBytes > 0 {
+			line += localizedText(language, "    占用峰值: ", "    Peak space: ") + plan.Units.Bytes(float64(pathPlan.PeakSpaceBytes))
+		}
+		if pathPlan.Missing {
+			line += localizedText(language, "    (不存在，运行时创建)", "    (missing, created when the test runs)")
+		}
+		text.WriteString(line + "\n")
+		for _, step := range pathPlan.Steps {
+			budget := localizedText(language, "不限时", "no limit")
+			if step.BudgetMS > 0 {
+				budget = (time.Duration(step.BudgetMS) * time.Millisecond).String()
+			}
+			text.WriteString(fmt.Sprintf("  [%s, %s] %s\n", step.Name, budget, strings.Join(step.Command, " ")))
+		}
+	}
+	text.WriteString(renderMountAliases(language, plan.Aliases))
+	text.WriteString(localizedText(language, "磁盘占用峰值: ", "Peak disk space: ") + plan.Units.Bytes(float64(plan.PeakSpaceBytes)) + "\n")
+	text.WriteString(localizedText(language, "时间预算: ", "Time budget: ") + (time.Duration(plan.BudgetMS) * time.Millisecond).String())
+	text.WriteString(localizedText(language, "（不含不限时的步骤）", " (steps without a limit not included)") + "\n")
+	for _, note := range plan.Notes {
+		if localized, ok := planNotes[note]; ok {
+			note = localizedText(language, localized[0], localized[1])
+		}
+		text.WriteString(localizedText(language, "说明: ", "Note: ") + note + "\n")
+	}
+	if plan.Error != "" {
+		text.WriteString(localizedText(language, "错误: ", "Error: ") + plan.Error + "\n")
+	}
+	return text.String()
+}
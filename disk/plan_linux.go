@@ -0,0 +1,18 @@
+//go:build linux
+
+package disk
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// planWritable 通过access(2)判断目录是否可写，只读挂载返回EROFS，不创建探测文件
+func planWritable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	return unix.Access(path, unix.W_OK) == nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package disk
+
+import "os"
+
+// planWritable 非Linux系统按目录权限位估计是否可写，不创建探测文件
+func planWritable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	return info.Mode().Perm()&0o222 != 0
+}
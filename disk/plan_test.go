@@ -0,0 +1,89 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func assertEmptyDir(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("dry run touched %s: %v %v", dir, entries, err)
+	}
+}
+
+func TestDryRunPlansLegacyFioWithoutWriting(t *testing.T) {
+	dir := t.TempDir()
+	result := RunTest(TestOptions{Method: "fio", Language: "en", Paths: []string{dir}, DryRun: true, FioSize: "64M", FioRuntime: 2 * time.Second, FioBlockSizes: []string{"4k", "1m"}})
+	assertEmptyDir(t, dir)
+	if result.Plan == nil || result.Fio != nil || len(result.Plan.Paths) != 1 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	plan := *result.Plan
+	steps := plan.Paths[0].Steps
+	if len(steps) != 3 || steps[0].Name != "setup" || steps[0].BudgetMS != 0 || steps[2].BudgetMS != 7000 {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+	command := strings.Join(steps[1].Command, " ")
+	if !strings.Contains(command, "--filename="+filepath.Join(dir, "test.fio")) || !strings.Contains(command, "--size=64M") || !strings.Contains(command, "--runtime=2") {
+		t.Fatalf("unexpected command %q", command)
+	}
+	if plan.PeakSpaceBytes != 64<<20 || plan.BudgetMS != 14000 {
+		t.Fatalf("peak=%d budget=%d", plan.PeakSpaceBytes, plan.BudgetMS)
+	}
+	for _, want := range []string{"Dry run:", "Peak disk space: 67.11 MB", "Time budget: 14s", "[rand_rw_1m, 7s] fio --name=rand_rw_1m"} {
+		if !strings.Contains(result.Text, want) {
+			t.Fatalf("plan text missing %q:\n%s", want, result.Text)
+		}
+	}
+}
+
+func TestDryRunPlansDDPatternSource(t *testing.T) {
+	dir := t.TempDir()
+	result := RunTest(TestOptions{Method: "dd", Paths: []string{dir}, DryRun: true, DDTests: []DDTestSpec{{BlockSize: "1M", TotalSize: "8M"}}, DataPattern: DataPattern{Kind: "random"}})
+	assertEmptyDir(t, dir)
+	steps := result.Plan.Paths[0].Steps
+	if len(steps) != 2 || steps[0].Command[1] != "if="+filepath.Join(dir, "pattern_temp") || steps[1].Command[2] != "of="+getDevNullPath() {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+	if result.Plan.PeakSpaceBytes != 16<<20 {
+		t.Fatalf("pattern source not counted: %d", result.Plan.PeakSpaceBytes)
+	}
+}
+
+func TestDryRunMatrixDoesNotAcquireFio(t *testing.T) {
+	dir := t.TempDir()
+	provider := func(context.Context) (fioAcquisition, error) {
+		t.Fatal("dry run acquired fio")
+		return fioAcquisition{}, nil
+	}
+	result := runFioMatrixWithDeps(context.Background(), MatrixConfig{Path: dir, SizeBytes: 16 << 20, Runtime: 10 * time.Second, DryRun: true}, StandardFioScenarios(), 40*time.Second, provider, nil)
+	assertEmptyDir(t, dir)
+	if result.Status != "planned" || result.Plan == nil || len(result.Plan.Paths[0].Steps) != len(StandardFioScenarios()) {
+		t.Fatalf("unexpected plan %s %+v", result.Status, result.Plan)
+	}
+	// 演练结果不能在导出格式中显示为通过
+	var junit, metrics bytes.Buffer
+	if err := WriteReport(&junit, "junit", MatrixReport(result)); err != nil || WriteReport(&metrics, "prometheus", MatrixReport(result)) != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(junit.String(), `failures="0" skipped="8"`) || strings.Count(junit.String(), `<skipped message="planned">`) != 8 {
+		t.Fatalf("dry-run scenarios were not skipped:\n%s", junit.String())
+	}
+	if !strings.Contains(metrics.String(), `disktest_up{status="planned"} 0`) {
+		t.Fatalf("dry run reported as up:\n%s", metrics.String())
+	}
+	// 40s分给8个场景，每个场景最多5s
+	if step := result.Plan.Paths[0].Steps[0]; step.BudgetMS != 5000 || !strings.Contains(strings.Join(step.Command, " "), "--runtime=5") {
+		t.Fatalf("unexpected step %+v", step)
+	}
+	if result.Plan.PeakSpaceBytes != 17<<20 || result.Plan.BudgetMS != 40000 {
+		t.Fatalf("peak=%d budget=%d", result.Plan.PeakSpaceBytes, result.Plan.BudgetMS)
+	}
+}
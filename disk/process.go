@@ -0,0 +1,19 @@
+package disk
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// processWaitDelay 进程组被终止后等待输出管道关闭的最长时间
+const processWaitDelay = 2 * time.Second
+
+// benchmarkCommand 创建fio/dd子进程：子进程在独立的进程组中运行，取消或超时时终止整个进程组，
+// 避免fio的numjobs工作进程在父进程被结束后继续读写测试文件并占用输出管道
+func benchmarkCommand(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	setProcessGroup(cmd)
+	cmd.WaitDelay = processWaitDelay
+	return cmd
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package disk
+
+import "os/exec"
+
+// setProcessGroup 非Unix系统沿用默认的结束方式，由WaitDelay保证按时返回
+func setProcessGroup(cmd *exec.Cmd) {}
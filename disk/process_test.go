@@ -0,0 +1,53 @@
+//go:build unix
+
+package disk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestBenchmarkCommandKillsForkedWorkersOnCancel(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "worker.pid")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	// 模拟fio：父进程派生工作进程，工作进程继承输出管道
+	_, err := benchmarkCommand(ctx, "sh", "-c", "sleep 30 & echo $! > "+pidFile+"; wait").CombinedOutput()
+	if err == nil {
+		t.Fatal("canceled command reported success")
+	}
+	if elapsed := time.Since(started); elapsed > processWaitDelay+time.Second {
+		t.Fatalf("canceled command returned after %s", elapsed)
+	}
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			t.Fatalf("forked worker %d survived cancellation", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// processAlive 已被结束但尚未回收的僵尸进程视为已退出
+func processAlive(pid int) bool {
+	if syscall.Kill(pid, 0) != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	return err != nil || !strings.Contains(string(stat), ") Z ")
+}
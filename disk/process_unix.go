@@ -0,0 +1,22 @@
+//go:build unix
+
+package disk
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 子进程单独成组，取消时向整个进程组发送SIGKILL
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+package disk
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+type prometheusFamily struct {
+	name, help string
+	value      func(ReportMetric) (float64, bool)
+	quantile   string
+}
+
+var prometheusFamilies = []prometheusFamily{
+	{name: "disktest_fio_bandwidth_bytes_per_second", help: "FIO bandwidth per scenario and direction.", value: func(metric ReportMetric) (float64, bool) {
+		return float64(metric.BandwidthBytesPerSecond), true
+	}},
+	{name: "disktest_fio_iops", help: "FIO I/O operations per second per scenario and direction.", value: func(metric ReportMetric) (float64, bool) {
+		return metric.IOPS, true
+	}},
+	{name: "disktest_fio_completion_latency_seconds", help: "FIO completion latency percentiles.", quantile: "0.5", value: func(metric ReportMetric) (float64, bool) {
+		return float64(metric.LatencyP50NS) / 1e9, metric.LatencyP50NS > 0
+	}},
+	{name: "disktest_fio_completion_latency_seconds", quantile: "0.95", value: func(metric ReportMetric) (float64, bool) {
+		return float64(metric.LatencyP95NS) / 1e9, metric.LatencyP95NS > 0
+	}},
+	{name: "disktest_fio_completion_latency_seconds", quantile: "0.99", value: func(metric ReportMetric) (float64, bool) {
+		return float64(metric.LatencyP99NS) / 1e9, metric.LatencyP99NS > 0
+	}},
+}
+
+// writePrometheusReport renders the report metrics as gauges in the
+// Prometheus text exposition format, or OpenMetrics when openMetrics is set.
+func writePrometheusReport(w io.Writer, report Report, openMetrics bool) error {
+	var builder strings.Builder
+	up := 0
+	if report.Status == "ok" {
+		up = 1
+	}
+	builder.WriteString("# HELP disktest_up Whether the last disk benchmark finished with status ok.\n")
+	builder.WriteString("# TYPE disktest_up gauge\n")
+	fmt.Fprintf(&builder, "disktest_up{status=\"%s\"} %d\n", prometheusLabelEscaper.Replace(report.Status), up)
+	for _, family := range prometheusFamilies {
+		if family.help != "" {
+			fmt.Fprintf(&builder, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name)
+		}
+		for _, metric := range report.Metrics {
+			value, ok := family.value(metric)
+			if !ok {
+				continue
+			}
+			labels := []string{
+				`path="` + prometheusLabelEscaper.Replace(metric.Path) + `"`,
+				`scenario_id="` + prometheusLabelEscaper.Replace(metric.ScenarioID) + `"`,
+				`direction="` + prometheusLabelEscaper.Replace(metric.Direction) + `"`,
+				`engine="` + prometheusLabelEscaper.Replace(metric.Engine) + `"`,
+			}
+			if family.quantile != "" {
+				labels = append(labels, `quantile="`+family.quantile+`"`)
+			}
+			fmt.Fprintf(&builder, "%s{%s} %s\n", family.name, strings.Join(labels, ","), strconv.FormatFloat(value, 'g', -1, 64))
+		}
+	}
+	if openMetrics {
+		builder.WriteString("# EOF\n")
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+// WriteReportFile renders report into path atomically: the output is written
+// to a temporary sibling, synced and renamed over the destination, so a
+// node_exporter textfile collector never scrapes a half-written file.
+func WriteReportFile(path, format string, report Report) error {
+	directory := filepath.Dir(path)
+	temporary, err := os.CreateTemp(directory, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	temporaryPath := temporary.Name()
+	defer os.Remove(temporaryPath)
+	if err := WriteReport(temporary, format, report); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Sync(); err != nil {
+		temporary.Close()
+		return err
+	}
+	if err := temporary.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temporaryPath, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
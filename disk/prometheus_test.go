@@ -0,0 +1,63 @@
+package disk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrometheusReportLabelsMetrics(t *testing.T) {
+	result := fixtureMatrixResult()
+	result.Path, result.IOEngine = `/data/"x"`, "libaio"
+	var output bytes.Buffer
+	if err := WriteReport(&output, "prometheus", MatrixReport(result)); err != nil {
+		t.Fatal(err)
+	}
+	text := output.String()
+	for _, want := range []string{
+		`disktest_up{status="error"} 0`,
+		`disktest_fio_bandwidth_bytes_per_second{path="/data/\"x\"",scenario_id="4k-q1-read",direction="read",engine="libaio"} 4.096e+06`,
+		`disktest_fio_completion_latency_seconds{path="/data/\"x\"",scenario_id="4k-q1-read",direction="read",engine="libaio",quantile="0.5"} 9e-05`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("prometheus output is missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Count(text, "# TYPE disktest_fio_completion_latency_seconds") != 1 || strings.Contains(text, "# EOF") {
+		t.Fatalf("unexpected prometheus metadata:\n%s", text)
+	}
+}
+
+func TestOpenMetricsReportEndsWithEOF(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteReport(&output, "openmetrics", MatrixReport(fixtureMatrixResult())); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(output.String(), "# EOF\n") {
+		t.Fatalf("openmetrics output does not end with EOF marker:\n%s", output.String())
+	}
+}
+
+func TestWriteReportFileReplacesAtomically(t *testing.T) {
+	directory := t.TempDir()
+	path := filepath.Join(directory, "disktest.prom")
+	if err := os.WriteFile(path, []byte("stale"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteReportFile(path, "prometheus", MatrixReport(fixtureMatrixResult())); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), "# HELP disktest_up") {
+		t.Fatalf("textfile = %q err=%v", data, err)
+	}
+	entries, _ := os.ReadDir(directory)
+	if len(entries) != 1 {
+		t.Fatalf("temporary textfile left behind: %v", entries)
+	}
+	if err := WriteReportFile(path, "yaml", Report{}); err == nil {
+		t.Fatal("expected unknown format error")
+	}
+}
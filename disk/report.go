@@ -0,0 +1,471 @@
+package disk
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Report is the format-neutral view of a benchmark result. Structured and
+// legacy results are converted into it once, and every registered formatter
+// renders the same rows and test cases.
+type Report struct {
+	Title string
+	// Language selects zh or en text for the human-readable table format.
+	Language string
+	// Units selects SI or IEC bandwidth units for the table format and is
+	// recorded in the table and CSV output.
+	Units   UnitFormat
+	Status  string
+	Columns []string
+	Rows    [][]string
+	Cases   []ReportCase
+	Metrics []ReportMetric
+}
+
+// ReportCase is one pass/fail unit for CI-oriented formats such as JUnit.
+// Skipped marks a case that was not run, such as a dry-run plan.
+type ReportCase struct {
+	Suite      string
+	Name       string
+	Failure    string
+	Skipped    string
+	DurationMS int64
+}
+
+// ReportMetric is one FIO measurement with the labels metric exposition
+// formats attach to it.
+type ReportMetric struct {
+	Path   string
+	Engine string
+	FioMetrics
+}
+
+type ReportFormatter func(io.Writer, Report) error
+
+var (
+	reportFormattersMu sync.RWMutex
+	reportFormatters   = map[string]ReportFormatter{
+		"csv":      writeCSVReport,
+		"markdown": writeMarkdownReport,
+		"html":     writeHTMLReport,
+		"junit":    writeJUnitReport,
+		"table":    writeTableReport,
+		"prometheus": func(w io.Writer, report Report) error {
+			return writePrometheusReport(w, report, false)
+		},
+		"openmetrics": func(w io.Writer, report Report) error {
+			return writePrometheusReport(w, report, true)
+		},
+	}
+)
+
+// RegisterReportFormatter adds or replaces an output format by name.
+func RegisterReportFormatter(name string, formatter ReportFormatter) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || formatter == nil {
+		return
+	}
+	reportFormattersMu.Lock()
+	defer reportFormattersMu.Unlock()
+	reportFormatters[name] = formatter
+}
+
+// ReportFormats lists the registered output format names in sorted order.
+func ReportFormats() []string {
+	reportFormattersMu.RLock()
+	defer reportFormattersMu.RUnlock()
+	names := make([]string, 0, len(reportFormatters))
+	for name := range reportFormatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteReport renders report with the formatter registered under format.
+func WriteReport(w io.Writer, format string, report Report) error {
+	reportFormattersMu.RLock()
+	formatter, exists := reportFormatters[strings.ToLower(strings.TrimSpace(format))]
+	reportFormattersMu.RUnlock()
+	if !exists {
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	return formatter(w, report)
+}
+
+var matrixReportColumns = []string{
+	"path", "scenario_id", "direction", "bandwidth_bytes_per_second", "iops",
+	"latency_p50_ns", "latency_p95_ns", "latency_p99_ns", "status", "error",
+}
+
+// MatrixReport converts one structured FIO matrix into rows and one test case
+// per configured scenario. Scenarios that did not complete fail with the
+// matrix status; a non-ok matrix whose scenarios all completed adds a
+// failing "matrix" case instead.
+func MatrixReport(result MatrixResult) Report {
+	report := Report{Title: "disktest " + result.SchemaVersion, Status: result.Status, Columns: matrixReportColumns, Units: result.Units}
+	appendMatrixReport(&report, result)
+	return report
+}
+
+// MultiPathReport converts a multi-path matrix with one JUnit suite per path.
+func MultiPathReport(result MultiPathResult) Report {
+	report := Report{Title: "disktest " + result.SchemaVersion, Status: result.Status, Columns: matrixReportColumns, Units: result.Units}
+	for _, pathResult := range result.Paths {
+		appendMatrixReport(&report, pathResult)
+	}
+	if len(result.Paths) == 0 {
+		report.Rows = append(report.Rows, []string{"", "", "", "", "", "", "", "", result.Status, result.Error})
+		report.Cases = append(report.Cases, ReportCase{Suite: "disktest", Name: "paths", Failure: reportFailure(result.Status, result.Error)})
+	}
+	return report
+}
+
+func appendMatrixReport(report *Report, result MatrixResult) {
+	suite := result.Path
+	if suite == "" {
+		suite = "disktest"
+	}
+	completed := make(map[string]struct{})
+	scenarios := append([]string{}, result.Scenarios...)
+	for _, metric := range result.Metrics {
+		report.Metrics = append(report.Metrics, ReportMetric{Path: result.Path, Engine: result.IOEngine, FioMetrics: metric})
+		report.Rows = append(report.Rows, []string{
+			result.Path, metric.ScenarioID, metric.Direction,
+			strconv.FormatUint(metric.BandwidthBytesPerSecond, 10),
+			strconv.FormatFloat(metric.IOPS, 'f', 2, 64),
+			strconv.FormatUint(metric.LatencyP50NS, 10),
+			strconv.FormatUint(metric.LatencyP95NS, 10),
+			strconv.FormatUint(metric.LatencyP99NS, 10),
+			"ok", "",
+		})
+		if _, exists := completed[metric.ScenarioID]; exists {
+			continue
+		}
+		completed[metric.ScenarioID] = struct{}{}
+		if !slices.Contains(scenarios, metric.ScenarioID) {
+			scenarios = append(scenarios, metric.ScenarioID)
+		}
+	}
+	// 每个配置的场景都生成用例，未完成的场景按矩阵状态记为失败；演练不运行场景
+	failed := false
+	for _, scenarioID := range scenarios {
+		testCase := ReportCase{Suite: suite, Name: scenarioID}
+		if result.Status == "planned" {
+			testCase.Skipped = "planned"
+		} else if _, exists := completed[scenarioID]; !exists && result.Plan == nil {
+			testCase.Failure = reportFailure(result.Status, result.Error)
+			if testCase.Failure == "" {
+				testCase.Failure = "error: no_metrics"
+			}
+			failed = true
+		}
+		report.Cases = append(report.Cases, testCase)
+	}
+	if result.Status != "ok" {
+		report.Rows = append(report.Rows, []string{result.Path, "", "", "", "", "", "", "", result.Status, result.Error})
+		if !failed && result.Status != "planned" {
+			report.Cases = append(report.Cases, ReportCase{Suite: suite, Name: "matrix", Failure: reportFailure(result.Status, result.Error), DurationMS: result.DurationMS})
+		}
+	}
+}
+
+func reportFailure(status, message string) string {
+	if status == "ok" {
+		return ""
+	}
+	if message == "" {
+		return status
+	}
+	return status + ": " + message
+}
+
+var fioLegacyReportColumns = []string{
+	"path", "block_size", "read_bytes_per_second", "read_iops", "write_bytes_per_second", "write_iops",
+	"total_bytes_per_second", "total_iops",
+}
+
+var ddLegacyReportColumns = []string{
+	"path", "device", "block_size", "write_status", "write_bytes_per_second", "write_iops",
+	"read_status", "read_bytes_per_second", "read_iops", "read_mode", "error",
+}
+
+// TestResultReport converts a legacy benchmark result into a report. FIO and
+// DD rows come from the typed measurements, so the notes printed around the
+// rendered table never become data rows; Winsat output is split from text.
+func TestResultReport(result TestResult) Report {
+	title := "disktest " + result.Method
+	var report Report
+	switch {
+	case result.Fio != nil:
+		report = FioLegacyReport(title, *result.Fio)
+	case result.DD != nil:
+		report = DDLegacyReport(title, *result.DD)
+	default:
+		report = LegacyReport(title, result.Text)
+	}
+	if result.Status != "" {
+		report.Status = result.Status
+	}
+	// 演练没有运行任何测试，用例记为跳过而非通过或失败
+	if report.Status == "planned" {
+		for index := range report.Cases {
+			report.Cases[index].Failure, report.Cases[index].Skipped = "", "planned"
+		}
+	}
+	return report
+}
+
+// FioLegacyReport converts typed legacy FIO rows with one test case per path
+// and block size. Each collected error adds a failing case.
+func FioLegacyReport(title string, result FioLegacyResult) Report {
+	report := Report{Title: title, Status: result.Status, Columns: fioLegacyReportColumns, Units: result.Units}
+	for _, row := range result.Results {
+		report.Rows = append(report.Rows, []string{
+			row.Path, row.BlockSize,
+			formatReportFloat(row.ReadBytesPerSecond), formatReportFloat(row.ReadIOPS),
+			formatReportFloat(row.WriteBytesPerSecond), formatReportFloat(row.WriteIOPS),
+			formatReportFloat(row.TotalBytesPerSecond), formatReportFloat(row.TotalIOPS),
+		})
+		report.Cases = append(report.Cases, ReportCase{Suite: row.Path, Name: row.BlockSize})
+	}
+	for _, message := range result.Errors {
+		report.Cases = append(report.Cases, ReportCase{Suite: title, Name: "fio", Failure: reportFailure("error", message)})
+	}
+	appendLegacyStatusCase(&report, title, result.Error)
+	return report
+}
+
+// DDLegacyReport converts typed legacy DD rows with one test case per path and
+// block; a row that did not write or read successfully fails its case.
+func DDLegacyReport(title string, result DDLegacyResult) Report {
+	report := Report{Title: title, Status: result.Status, Columns: ddLegacyReportColumns, Units: result.Units}
+	for _, row := range result.Results {
+		if row.WriteStatus == "" && row.ReadStatus == "" {
+			continue
+		}
+		report.Rows = append(report.Rows, []string{
+			row.Path, row.Device, row.BlockName,
+			row.WriteStatus, formatReportFloat(row.Write.BytesPerSecond), formatReportFloat(row.Write.IOPS),
+			row.ReadStatus, formatReportFloat(row.Read.BytesPerSecond), formatReportFloat(row.Read.IOPS),
+			row.ReadMode, row.FailureReason,
+		})
+		testCase := ReportCase{Suite: row.Path, Name: row.BlockName}
+		if row.WriteStatus != "ok" || row.ReadStatus != "ok" {
+			testCase.Failure = reportFailure("error", row.FailureReason)
+		}
+		report.Cases = append(report.Cases, testCase)
+	}
+	appendLegacyStatusCase(&report, title, result.Error)
+	return report
+}
+
+// appendLegacyStatusCase 没有任何测试用例时补一个携带整体状态的用例，避免空的JUnit结果被视为通过
+func appendLegacyStatusCase(report *Report, title, message string) {
+	if len(report.Cases) > 0 {
+		return
+	}
+	report.Cases = append(report.Cases, ReportCase{Suite: title, Name: "benchmark", Failure: reportFailure(report.Status, message)})
+}
+
+func formatReportFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+var legacyColumnSeparator = regexp.MustCompile(`\s{2,}`)
+
+// legacyFailureMarkers are the localized cell texts the legacy renderers use
+// for a failed or unparsable measurement.
+var legacyFailureMarkers = []string{"failed", "Unable to parse", "canceled", "失败", "无法解析", "已取消"}
+
+// LegacyReport splits a rendered legacy table back into columns. It is only
+// used for output that has no typed result, such as Winsat.
+func LegacyReport(title, text string) Report {
+	report := Report{Title: title, Status: "ok"}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		cells := legacyColumnSeparator.Split(strings.TrimSpace(line), -1)
+		if report.Columns == nil {
+			report.Columns = cells
+			continue
+		}
+		report.Rows = append(report.Rows, cells)
+		testCase := ReportCase{Suite: title, Name: strings.Join(cells[:min(2, len(cells))], " ")}
+		for _, cell := range cells {
+			for _, marker := range legacyFailureMarkers {
+				if strings.Contains(cell, marker) && testCase.Failure == "" {
+					testCase.Failure = cell
+				}
+			}
+		}
+		if testCase.Failure != "" {
+			report.Status = "partial"
+		}
+		report.Cases = append(report.Cases, testCase)
+	}
+	if len(report.Rows) == 0 {
+		report.Status = "unavailable"
+		report.Cases = append(report.Cases, ReportCase{Suite: title, Name: "benchmark", Failure: "unavailable"})
+	}
+	return report
+}
+
+// writeCSVReport 在最后一列记录运行所用的单位制，数值列始终为原始的bytes/s
+func writeCSVReport(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(append(slices.Clone(report.Columns), "units")); err != nil {
+		return err
+	}
+	units := report.Units.String()
+	for _, row := range report.Rows {
+		if err := writer.Write(append(slices.Clone(row), units)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeMarkdownReport(w io.Writer, report Report) error {
+	escape := func(cells []string) string {
+		escaped := make([]string, len(cells))
+		for index, cell := range cells {
+			escaped[index] = strings.ReplaceAll(strings.ReplaceAll(cell, "|", "\\|"), "\n", " ")
+		}
+		return "| " + strings.Join(escaped, " | ") + " |\n"
+	}
+	var builder strings.Builder
+	if report.Title != "" {
+		builder.WriteString("## " + report.Title + "\n\n")
+	}
+	if report.Status != "" {
+		builder.WriteString("Status: `" + report.Status + "`\n\n")
+	}
+	builder.WriteString(escape(report.Columns))
+	separators := make([]string, len(report.Columns))
+	for index := range separators {
+		separators[index] = "---"
+	}
+	builder.WriteString("|" + strings.Join(separators, "|") + "|\n")
+	for _, row := range report.Rows {
+		builder.WriteString(escape(row))
+	}
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body{font-family:sans-serif;margin:2em;color:#222}
+table{border-collapse:collapse}
+th,td{border:1px solid #ccc;padding:4px 8px;text-align:left}
+th{background:#f0f0f0}
+.status{font-weight:bold}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="status">Status: {{.Status}}</p>
+<table>
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
+
+func writeHTMLReport(w io.Writer, report Report) error {
+	return htmlReportTemplate.Execute(w, report)
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+func writeJUnitReport(w io.Writer, report Report) error {
+	document := junitTestSuites{Name: report.Title}
+	suiteIndex := make(map[string]int)
+	for _, testCase := range report.Cases {
+		index, exists := suiteIndex[testCase.Suite]
+		if !exists {
+			index = len(document.Suites)
+			suiteIndex[testCase.Suite] = index
+			document.Suites = append(document.Suites, junitTestSuite{Name: testCase.Suite})
+		}
+		suite := &document.Suites[index]
+		entry := junitTestCase{
+			ClassName: "disktest",
+			Name:      testCase.Name,
+			Time:      strconv.FormatFloat(float64(testCase.DurationMS)/1000, 'f', 3, 64),
+		}
+		if testCase.Failure != "" {
+			entry.Failure = &junitFailure{Message: testCase.Failure, Text: testCase.Failure}
+			suite.Failures++
+			document.Failures++
+		} else if testCase.Skipped != "" {
+			entry.Skipped = &junitSkipped{Message: testCase.Skipped}
+			suite.Skipped++
+			document.Skipped++
+		}
+		suite.Tests++
+		document.Tests++
+		suite.TestCases = append(suite.TestCases, entry)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,113 @@
+package disk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func fixtureMatrixResult() MatrixResult {
+	return MatrixResult{
+		SchemaVersion: "goecs.disk/v1", Status: "error", Path: "/data", Error: "fio_failed",
+		Scenarios: []string{"4k-q1-read", "1m-q8-write", "4k-q32-read"},
+		Metrics: []FioMetrics{
+			{ScenarioID: "4k-q1-read", Direction: "read", BandwidthBytesPerSecond: 4096000, IOPS: 1000, LatencyP50NS: 90000},
+			{ScenarioID: "1m-q8-write", Direction: "write", BandwidthBytesPerSecond: 1 << 30, IOPS: 1024},
+		},
+	}
+}
+
+func TestMatrixReportCSV(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteReport(&output, "csv", MatrixReport(fixtureMatrixResult())); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&output).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0][1] != "scenario_id" || records[1][3] != "4096000" || records[3][8] != "error" {
+		t.Fatalf("unexpected CSV records: %v", records)
+	}
+}
+
+func TestMatrixReportJUnitFailsFromStatus(t *testing.T) {
+	var output bytes.Buffer
+	if err := WriteReport(&output, "JUnit", MatrixReport(fixtureMatrixResult())); err != nil {
+		t.Fatal(err)
+	}
+	var document junitTestSuites
+	if err := xml.Unmarshal(output.Bytes(), &document); err != nil {
+		t.Fatalf("invalid JUnit XML: %v\n%s", err, output.String())
+	}
+	if document.Tests != 3 || document.Failures != 1 || len(document.Suites) != 1 || document.Suites[0].Name != "/data" {
+		t.Fatalf("unexpected JUnit document: %+v", document)
+	}
+	if failed := document.Suites[0].TestCases[2]; failed.Name != "4k-q32-read" || failed.Failure == nil || failed.Failure.Message != "error: fio_failed" {
+		t.Fatalf("scenario without metrics did not fail: %+v", failed)
+	}
+	// 场景全部完成但矩阵状态异常时，仍以矩阵用例标记失败
+	result := fixtureMatrixResult()
+	result.Scenarios = result.Scenarios[:2]
+	if report := MatrixReport(result); len(report.Cases) != 3 || report.Cases[2].Name != "matrix" || report.Cases[2].Failure == "" {
+		t.Fatalf("unexpected cases %+v", report.Cases)
+	}
+}
+
+func TestMarkdownAndHTMLReportsEscapeCells(t *testing.T) {
+	report := Report{Title: "t", Columns: []string{"a"}, Rows: [][]string{{"x|<b>"}}}
+	var markdown, html bytes.Buffer
+	if err := WriteReport(&markdown, "markdown", report); err != nil || !strings.Contains(markdown.String(), `x\|<b>`) {
+		t.Fatalf("markdown = %q err=%v", markdown.String(), err)
+	}
+	if err := WriteReport(&html, "html", report); err != nil || strings.Contains(html.String(), "<b>") {
+		t.Fatalf("html = %q err=%v", html.String(), err)
+	}
+}
+
+func TestLegacyReportSplitsRenderedTable(t *testing.T) {
+	row := "/root             4k        1.00 MB/s(1)            Write failed            3.00 MB/s(3)\n"
+	report := LegacyReport("fio", generateFioTestHeader("en", []string{"/root"})+row)
+	if len(report.Columns) != 5 || len(report.Rows) != 1 || report.Rows[0][2] != "1.00 MB/s(1)" {
+		t.Fatalf("unexpected legacy report: %+v", report)
+	}
+	if report.Status != "partial" || report.Cases[0].Failure != "Write failed" {
+		t.Fatalf("legacy failure was not detected: %+v", report)
+	}
+}
+
+func TestTestResultReportUsesTypedRows(t *testing.T) {
+	fio := &FioLegacyResult{Status: "partial", Errors: []string{"write failed"}, Results: []FioBlockResult{
+		{Path: "/root", BlockSize: "4k", ReadBytesPerSecond: 1e6, ReadIOPS: 250, TotalBytesPerSecond: 1e6, TotalIOPS: 250},
+	}, Fio: &FioInfo{Version: "fio-3.36", Source: "embedded"}}
+	report := TestResultReport(TestResult{Method: "fio", Status: fio.Status, Fio: fio, Text: RenderFioLegacy("en", *fio) + "Score: 90\n"})
+	if len(report.Rows) != 1 || report.Rows[0][2] != "1000000.00" || report.Status != "partial" {
+		t.Fatalf("unexpected fio report: %+v", report)
+	}
+	if len(report.Cases) != 2 || report.Cases[1].Failure != "error: write failed" {
+		t.Fatalf("fio errors were not reported: %+v", report.Cases)
+	}
+	dd := &DDLegacyResult{Status: "partial", Results: []DDResult{
+		{Path: "/a", BlockName: "4k", WriteStatus: "ok", ReadStatus: "failed", FailureReason: "read_failed", Write: DDMeasurement{BytesPerSecond: 2e6}},
+		{Path: "/a", BlockName: "1m"},
+	}}
+	report = TestResultReport(TestResult{Method: "dd", Status: "partial", DD: dd})
+	if len(report.Rows) != 1 || len(report.Cases) != 1 || report.Cases[0].Failure != "error: read_failed" {
+		t.Fatalf("unexpected dd report: %+v", report)
+	}
+	var output bytes.Buffer
+	if err := WriteReport(&output, "table", report); err != nil || !strings.Contains(output.String(), "2.00 MB/s") {
+		t.Fatalf("table did not humanize rates %q err=%v", output.String(), err)
+	}
+	if empty := TestResultReport(TestResult{Method: "dd", Status: "unavailable", DD: &DDLegacyResult{Status: "unavailable", Error: "dd_unavailable"}}); len(empty.Cases) != 1 || empty.Cases[0].Failure == "" {
+		t.Fatalf("empty result did not fail: %+v", empty)
+	}
+}
+
+func TestWriteReportRejectsUnknownFormat(t *testing.T) {
+	if err := WriteReport(&bytes.Buffer{}, "yaml", Report{}); err == nil {
+		t.Fatal("expected unknown format error")
+	}
+}
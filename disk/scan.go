@@ -0,0 +1,223 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+type ScanConfig struct {
+	Path          string
+	ChunkBytes    int64
+	MaxBytes      int64
+	AllowDevice   bool
+	OutlierFactor float64
+	MapBuckets    int
+	MaxDuration   time.Duration
+}
+
+type ScanBucket struct {
+	OffsetBytes   int64  `json:"offset_bytes"`
+	LengthBytes   int64  `json:"length_bytes"`
+	MeanLatencyNS uint64 `json:"mean_latency_ns"`
+	MaxLatencyNS  uint64 `json:"max_latency_ns"`
+}
+
+type ScanRegion struct {
+	OffsetBytes  int64  `json:"offset_bytes"`
+	LengthBytes  int64  `json:"length_bytes"`
+	MaxLatencyNS uint64 `json:"max_latency_ns"`
+}
+
+type ScanResult struct {
+	SchemaVersion           string       `json:"schema_version"`
+	Status                  string       `json:"status"`
+	BlockDevice             bool         `json:"block_device"`
+	HeldBy                  []string     `json:"held_by,omitempty"`
+	SizeBytes               int64        `json:"size_bytes"`
+	ScannedBytes            int64        `json:"scanned_bytes"`
+	ChunkBytes              int64        `json:"chunk_bytes"`
+	Chunks                  int          `json:"chunks"`
+	BandwidthBytesPerSecond uint64       `json:"bandwidth_bytes_per_second"`
+	LatencyP50NS            uint64       `json:"latency_p50_ns"`
+	LatencyP99NS            uint64       `json:"latency_p99_ns"`
+	LatencyMaxNS            uint64       `json:"latency_max_ns"`
+	LatencyMap              []ScanBucket `json:"latency_map,omitempty"`
+	Outliers                []ScanRegion `json:"outliers,omitempty"`
+	DurationMS              int64        `json:"duration_ms"`
+	// ReadMode tells whether the scan bypassed the page cache: direct
+	// (O_DIRECT), or possibly-cached when O_DIRECT was refused and latencies
+	// may reflect memory rather than the medium.
+	ReadMode string `json:"read_mode,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// maxScanOutliers bounds the report on badly degraded media where most chunks
+// would otherwise be listed individually.
+const maxScanOutliers = 100
+
+// RunSurfaceScan reads an existing file, image or (with AllowDevice) block
+// device sequentially and records the latency of every chunk. The target is
+// opened read-only and, where supported, with O_DIRECT so cached pages do not
+// hide slow regions; nothing is ever written.
+func RunSurfaceScan(ctx context.Context, config ScanConfig) (result ScanResult) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if config.ChunkBytes <= 0 {
+		config.ChunkBytes = 1 << 20
+	}
+	if config.ChunkBytes < 4096 {
+		config.ChunkBytes = 4096
+	}
+	if config.OutlierFactor <= 1 {
+		config.OutlierFactor = 5
+	}
+	if config.MapBuckets <= 0 {
+		config.MapBuckets = 64
+	}
+	result = ScanResult{SchemaVersion: "goecs.disk/scan-v1", Status: "ok", ChunkBytes: config.ChunkBytes}
+	started := time.Now()
+	defer func() { result.DurationMS = time.Since(started).Milliseconds() }()
+	if config.MaxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.MaxDuration)
+		defer cancel()
+	}
+	if config.Path == "" {
+		result.Status, result.Error = "unavailable", "scan_path_required"
+		return result
+	}
+	info, err := os.Stat(config.Path)
+	if err != nil {
+		result.Status, result.Error = "unavailable", stableTestPathError(err)
+		return result
+	}
+	switch mode := info.Mode(); {
+	case mode.IsRegular():
+	case mode&os.ModeDevice != 0 && mode&os.ModeCharDevice == 0:
+		if !config.AllowDevice {
+			result.Status, result.Error = "unavailable", "raw_device_forbidden"
+			return result
+		}
+		result.BlockDevice = true
+		result.HeldBy = deviceHolders(config.Path)
+	default:
+		result.Status, result.Error = "unavailable", "scan_target_not_file"
+		return result
+	}
+	file, readMode, err := openUncachedRead(config.Path)
+	if err != nil {
+		result.Status, result.Error = "unavailable", stableTestPathError(err)
+		return result
+	}
+	defer file.Close()
+	result.ReadMode = readMode
+	if readMode == "direct" {
+		// O_DIRECT要求读取长度与偏移按块对齐
+		config.ChunkBytes = roundUpAlignment(config.ChunkBytes)
+		result.ChunkBytes = config.ChunkBytes
+	}
+	size := info.Size()
+	if result.BlockDevice {
+		if size, err = file.Seek(0, io.SeekEnd); err != nil {
+			result.Status, result.Error = "error", "read_failed"
+			return result
+		}
+	}
+	result.SizeBytes = size
+	if config.MaxBytes > 0 && config.MaxBytes < size {
+		size = config.MaxBytes
+	}
+	latencies, scanned, readErr := readScanChunks(ctx, file, size, config.ChunkBytes, readMode == "direct")
+	result.ScannedBytes, result.Chunks = scanned, len(latencies)
+	summarizeScan(&result, latencies, config)
+	switch {
+	case ctx.Err() != nil:
+		result.Status, result.Error = matrixStopStatus(ctx.Err()), stableMatrixError(ctx.Err())
+	case readErr != nil:
+		result.Status, result.Error = "error", "read_failed"
+	}
+	return result
+}
+
+// readScanChunks 顺序读取并记录每块耗时；direct模式下末块按对齐长度读取，只计入size以内的字节
+func readScanChunks(ctx context.Context, file *os.File, size, chunkBytes int64, direct bool) ([]uint64, int64, error) {
+	buffer := alignedBuffer(int(chunkBytes))
+	latencies := make([]uint64, 0, size/chunkBytes+1)
+	var offset int64
+	for offset < size {
+		if err := ctx.Err(); err != nil {
+			return latencies, offset, err
+		}
+		length := min(chunkBytes, size-offset)
+		if direct {
+			length = roundUpAlignment(length)
+		}
+		started := time.Now()
+		read, err := file.ReadAt(buffer[:length], offset)
+		latencies = append(latencies, uint64(time.Since(started).Nanoseconds()))
+		offset += min(int64(read), size-offset)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return latencies, offset, err
+		}
+		if read == 0 {
+			break
+		}
+	}
+	return latencies, offset, nil
+}
+
+// summarizeScan folds per-chunk latencies into percentiles, a fixed-size
+// latency map and merged outlier regions relative to the median chunk.
+func summarizeScan(result *ScanResult, latencies []uint64, config ScanConfig) {
+	if len(latencies) == 0 {
+		return
+	}
+	var total uint64
+	for _, latency := range latencies {
+		total += latency
+	}
+	if total > 0 {
+		result.BandwidthBytesPerSecond = uint64(float64(result.ScannedBytes) / (float64(total) / float64(time.Second)))
+	}
+	sorted := append([]uint64(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	result.LatencyP50NS = sorted[(len(sorted)-1)*50/100]
+	result.LatencyP99NS = sorted[(len(sorted)-1)*99/100]
+	result.LatencyMaxNS = sorted[len(sorted)-1]
+	buckets := min(config.MapBuckets, len(latencies))
+	for bucket := 0; bucket < buckets; bucket++ {
+		first, last := bucket*len(latencies)/buckets, (bucket+1)*len(latencies)/buckets
+		entry := ScanBucket{OffsetBytes: int64(first) * config.ChunkBytes}
+		entry.LengthBytes = min(int64(last)*config.ChunkBytes, result.ScannedBytes) - entry.OffsetBytes
+		var sum uint64
+		for _, latency := range latencies[first:last] {
+			sum += latency
+			entry.MaxLatencyNS = max(entry.MaxLatencyNS, latency)
+		}
+		entry.MeanLatencyNS = sum / uint64(last-first)
+		result.LatencyMap = append(result.LatencyMap, entry)
+	}
+	threshold := uint64(float64(result.LatencyP50NS) * config.OutlierFactor)
+	for index, latency := range latencies {
+		if latency <= threshold {
+			continue
+		}
+		offset := int64(index) * config.ChunkBytes
+		length := min(config.ChunkBytes, result.ScannedBytes-offset)
+		if count := len(result.Outliers); count > 0 && result.Outliers[count-1].OffsetBytes+result.Outliers[count-1].LengthBytes == offset {
+			region := &result.Outliers[count-1]
+			region.LengthBytes += length
+			region.MaxLatencyNS = max(region.MaxLatencyNS, latency)
+			continue
+		}
+		if len(result.Outliers) == maxScanOutliers {
+			break
+		}
+		result.Outliers = append(result.Outliers, ScanRegion{OffsetBytes: offset, LengthBytes: length, MaxLatencyNS: latency})
+	}
+}
@@ -0,0 +1,54 @@
+package disk
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunSurfaceScanReadsFileWithoutModifyingIt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.bin")
+	if err := os.WriteFile(path, make([]byte, 10*4096+100), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.Stat(path)
+	result := RunSurfaceScan(context.Background(), ScanConfig{Path: path, ChunkBytes: 4096, MapBuckets: 4})
+	if result.Status != "ok" || result.Chunks != 11 || result.ScannedBytes != before.Size() || len(result.LatencyMap) != 4 {
+		t.Fatalf("unexpected scan result: %+v", result)
+	}
+	if runtime.GOOS == "linux" && result.ReadMode == "possibly-cached" {
+		t.Fatalf("surface scan on Linux may be served from the page cache")
+	}
+	after, _ := os.Stat(path)
+	if !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		t.Fatal("surface scan modified its target")
+	}
+}
+
+func TestRunSurfaceScanRejectsNonFileTargets(t *testing.T) {
+	for path, want := range map[string]string{
+		t.TempDir(): "scan_target_not_file",
+		os.DevNull:  "scan_target_not_file",
+		"":          "scan_path_required",
+	} {
+		if result := RunSurfaceScan(context.Background(), ScanConfig{Path: path}); result.Status != "unavailable" || result.Error != want {
+			t.Fatalf("unexpected result for %q: %+v", path, result)
+		}
+	}
+}
+
+func TestSummarizeScanMergesAdjacentOutliers(t *testing.T) {
+	result := ScanResult{ScannedBytes: 8 * 10}
+	summarizeScan(&result, []uint64{10, 10, 10, 500, 600, 10, 10, 900}, ScanConfig{ChunkBytes: 10, OutlierFactor: 5, MapBuckets: 2})
+	if len(result.Outliers) != 2 {
+		t.Fatalf("outliers = %+v", result.Outliers)
+	}
+	if first := result.Outliers[0]; first.OffsetBytes != 30 || first.LengthBytes != 20 || first.MaxLatencyNS != 600 {
+		t.Fatalf("first outlier = %+v", first)
+	}
+	if result.LatencyP50NS != 10 || result.LatencyMaxNS != 900 || len(result.LatencyMap) != 2 {
+		t.Fatalf("unexpected summary: %+v", result)
+	}
+}
@@ -0,0 +1,417 @@
+package disk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ScoreTable is the versioned reference table that turns benchmark numbers
+// into a 0-100 score and a device class. DefaultScoreTable documents the
+// built-in values; LoadScoreTable reads an override from JSON.
+type ScoreTable struct {
+	Version    string           `json:"version"`
+	Components []ScoreComponent `json:"components"`
+	// Classes are tried in order and the first one whose bounds all hold
+	// is reported, so the last entry usually has no bounds.
+	Classes []DeviceClass `json:"classes"`
+}
+
+// ScoreComponent scores one input on a logarithmic scale: Floor scores 0
+// and Reference scores 100, clamped to that range. ID is latency_4k_qd1,
+// iops_4k_qd32 or bandwidth_1m_seq for the structured matrix, and
+// iops_4k_mixed or bandwidth_1m_mixed for the legacy FIO table.
+type ScoreComponent struct {
+	ID        string  `json:"id"`
+	Weight    float64 `json:"weight"`
+	Reference float64 `json:"reference"`
+	Floor     float64 `json:"floor"`
+}
+
+// DeviceClass is a labeled range of inputs. A zero bound is not checked. A
+// bound on an input the benchmark did not measure stops classification and
+// the result is reported as unclassified, so a partial run never borrows the
+// label of a faster class. Latency, IOPS and BPS bound the structured matrix
+// inputs; the Mixed bounds bound the legacy FIO table inputs.
+type DeviceClass struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	LabelZh string `json:"label_zh,omitempty"`
+	// Throttled restricts the class to results that hit a cgroup limit.
+	Throttled    bool    `json:"throttled,omitempty"`
+	MinLatencyNS uint64  `json:"min_latency_ns,omitempty"`
+	MaxLatencyNS uint64  `json:"max_latency_ns,omitempty"`
+	MinIOPS      float64 `json:"min_iops,omitempty"`
+	MaxIOPS      float64 `json:"max_iops,omitempty"`
+	MinBPS       float64 `json:"min_bytes_per_second,omitempty"`
+	MaxBPS       float64 `json:"max_bytes_per_second,omitempty"`
+	MinMixedIOPS float64 `json:"min_mixed_iops,omitempty"`
+	MaxMixedIOPS float64 `json:"max_mixed_iops,omitempty"`
+	MinMixedBPS  float64 `json:"min_mixed_bytes_per_second,omitempty"`
+	MaxMixedBPS  float64 `json:"max_mixed_bytes_per_second,omitempty"`
+}
+
+// unclassifiedClass 输入不足以判断类别或没有类别匹配时的结果
+var unclassifiedClass = DeviceClass{ID: "unclassified", Label: "unclassified", LabelZh: "未分类"}
+
+// matrixScoreInputs 与 legacyScoreInputs 是两种测试模式各自能测得的评分输入
+var (
+	matrixScoreInputs = []string{"latency_4k_qd1", "iops_4k_qd32", "bandwidth_1m_seq"}
+	legacyScoreInputs = []string{"iops_4k_mixed", "bandwidth_1m_mixed"}
+)
+
+// ScoreInput holds the numbers a score is computed from; zero means not
+// measured.
+type ScoreInput struct {
+	// Latency4KQ1NS is the median 4k random read latency at queue depth 1.
+	Latency4KQ1NS uint64 `json:"latency_4k_qd1_ns,omitempty"`
+	// IOPS4KQ32 is the 4k random IOPS at a deep queue.
+	IOPS4KQ32 float64 `json:"iops_4k_qd32,omitempty"`
+	// SequentialBPS is the 1m transfer bandwidth in bytes per second.
+	SequentialBPS float64 `json:"bandwidth_1m_seq_bytes_per_second,omitempty"`
+	// IOPS4KMixed is the total IOPS of the legacy 4k random read/write row.
+	IOPS4KMixed float64 `json:"iops_4k_mixed,omitempty"`
+	// MixedBPS is the total bandwidth of the legacy 1m random read/write row.
+	MixedBPS float64 `json:"bandwidth_1m_mixed_bytes_per_second,omitempty"`
+	// Throttled is set when a measurement sat close to a cgroup limit.
+	Throttled bool `json:"throttled,omitempty"`
+}
+
+// ComponentScore is the score of one ScoreTable component.
+type ComponentScore struct {
+	ID    string  `json:"id"`
+	Value float64 `json:"value"`
+	Score float64 `json:"score"`
+}
+
+// Score is a composite 0-100 score with the matched device class.
+type Score struct {
+	TableVersion string           `json:"table_version"`
+	Score        float64          `json:"score"`
+	Class        string           `json:"class"`
+	Label        string           `json:"label"`
+	LabelZh      string           `json:"label_zh,omitempty"`
+	Input        ScoreInput       `json:"input"`
+	Components   []ComponentScore `json:"components"`
+}
+
+// DefaultScoreTable returns the built-in table. Components, weight and the
+// values scoring 0 and 100:
+//
+//	latency_4k_qd1    30%  10 ms .. 50 µs
+//	iops_4k_qd32      40%  100 .. 500k IOPS
+//	bandwidth_1m_seq  30%  10 MB/s .. 7 GB/s
+//
+// Classes, first match wins:
+//
+//	cgroup-throttled   a measurement hit a cgroup io.max limit
+//	nvme               ≤150 µs, ≥100k IOPS, ≥1.5 GB/s
+//	sata-ssd           ≤400 µs, ≥20k IOPS, ≥250 MB/s
+//	hdd                ≥2 ms, ≤2000 IOPS
+//	network-throttled  anything else, typically capped cloud or network volumes
+func DefaultScoreTable() ScoreTable {
+	return ScoreTable{
+		Version: "disk-score/v1",
+		Components: []ScoreComponent{
+			{ID: "latency_4k_qd1", Weight: 0.3, Reference: 50_000, Floor: 10_000_000},
+			{ID: "iops_4k_qd32", Weight: 0.4, Reference: 500_000, Floor: 100},
+			{ID: "bandwidth_1m_seq", Weight: 0.3, Reference: 7_000_000_000, Floor: 10_000_000},
+		},
+		Classes: []DeviceClass{
+			{ID: "cgroup-throttled", Label: "cgroup-throttled", LabelZh: "受cgroup限速", Throttled: true},
+			{ID: "nvme", Label: "NVMe SSD", LabelZh: "NVMe固态硬盘", MaxLatencyNS: 150_000, MinIOPS: 100_000, MinBPS: 1_500_000_000},
+			{ID: "sata-ssd", Label: "SATA SSD", LabelZh: "SATA固态硬盘", MaxLatencyNS: 400_000, MinIOPS: 20_000, MinBPS: 250_000_000},
+			{ID: "hdd", Label: "HDD", LabelZh: "机械硬盘", MinLatencyNS: 2_000_000, MaxIOPS: 2000},
+			{ID: "network-throttled", Label: "Network/throttled", LabelZh: "网络存储或限速"},
+		},
+	}
+}
+
+// DefaultLegacyScoreTable returns the built-in table for the legacy FIO
+// table, whose rows are 50/50 random read/write at iodepth 64 with 2 jobs
+// and measure no latency. Components, weight and the values scoring 0 and
+// 100:
+//
+//	iops_4k_mixed       50%  100 .. 500k IOPS (4k row, read+write)
+//	bandwidth_1m_mixed  50%  10 MB/s .. 7 GB/s (1m row, read+write)
+//
+// Classes, first match wins:
+//
+//	cgroup-throttled  a measurement hit a cgroup io.max limit
+//	nvme              ≥100k IOPS, ≥1.5 GB/s
+//	sata-ssd          ≥20k IOPS, ≥250 MB/s
+//	hdd-or-network    anything else; without latency an HDD cannot be told
+//	                  apart from a capped cloud or network volume
+func DefaultLegacyScoreTable() ScoreTable {
+	return ScoreTable{
+		Version: "disk-score-legacy/v2",
+		Components: []ScoreComponent{
+			{ID: "iops_4k_mixed", Weight: 0.5, Reference: 500_000, Floor: 100},
+			{ID: "bandwidth_1m_mixed", Weight: 0.5, Reference: 7_000_000_000, Floor: 10_000_000},
+		},
+		Classes: []DeviceClass{
+			{ID: "cgroup-throttled", Label: "cgroup-throttled", LabelZh: "受cgroup限速", Throttled: true},
+			{ID: "nvme", Label: "NVMe SSD", LabelZh: "NVMe固态硬盘", MinMixedIOPS: 100_000, MinMixedBPS: 1_500_000_000},
+			{ID: "sata-ssd", Label: "SATA SSD", LabelZh: "SATA固态硬盘", MinMixedIOPS: 20_000, MinMixedBPS: 250_000_000},
+			{ID: "hdd-or-network", Label: "HDD or network/throttled", LabelZh: "机械硬盘、网络存储或限速"},
+		},
+	}
+}
+
+// LoadScoreTable reads a ScoreTable override from a JSON file.
+func LoadScoreTable(filename string) (ScoreTable, error) {
+	var table ScoreTable
+	file, err := os.Open(filename)
+	if err != nil {
+		return table, err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&table); err != nil {
+		return ScoreTable{}, fmt.Errorf("invalid score table: %w", err)
+	}
+	return table, table.Validate()
+}
+
+// ValidateMatrix validates the table and reports components or class bounds
+// the structured FIO matrix cannot measure.
+func (table ScoreTable) ValidateMatrix() error {
+	return table.validateInputs(matrixScoreInputs)
+}
+
+// ValidateLegacy validates the table and reports components or class bounds
+// the legacy FIO table cannot measure.
+func (table ScoreTable) ValidateLegacy() error {
+	return table.validateInputs(legacyScoreInputs)
+}
+
+// validateInputs 除通用校验外，要求所有组件与类别约束都使用当前模式可测得的输入
+func (table ScoreTable) validateInputs(inputs []string) error {
+	if err := table.Validate(); err != nil {
+		return err
+	}
+	for _, component := range table.Components {
+		if !slices.Contains(inputs, component.ID) {
+			return fmt.Errorf("score component %s is not measured in this mode, use %s", component.ID, strings.Join(inputs, ", "))
+		}
+	}
+	for _, class := range table.Classes {
+		for _, input := range class.boundInputs() {
+			if !slices.Contains(inputs, input) {
+				return fmt.Errorf("score class %s bounds %s, which is not measured in this mode", class.ID, input)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate reports unknown component IDs, unusable reference values and
+// unlabeled classes.
+func (table ScoreTable) Validate() error {
+	if strings.TrimSpace(table.Version) == "" {
+		return fmt.Errorf("score table version is required")
+	}
+	var weights float64
+	for _, component := range table.Components {
+		switch component.ID {
+		case "latency_4k_qd1", "iops_4k_qd32", "bandwidth_1m_seq", "iops_4k_mixed", "bandwidth_1m_mixed":
+		default:
+			return fmt.Errorf("unknown score component %q", component.ID)
+		}
+		if component.Weight < 0 || component.Reference <= 0 || component.Floor <= 0 || component.Reference == component.Floor {
+			return fmt.Errorf("score component %s needs a non-negative weight and distinct positive reference and floor", component.ID)
+		}
+		weights += component.Weight
+	}
+	if weights <= 0 {
+		return fmt.Errorf("score table needs at least one weighted component")
+	}
+	if len(table.Classes) == 0 {
+		return fmt.Errorf("score table needs at least one class")
+	}
+	for _, class := range table.Classes {
+		if class.ID == "" || class.Label == "" {
+			return fmt.Errorf("every score class needs an id and a label")
+		}
+	}
+	return nil
+}
+
+// Score computes the composite score and class for input. It reports false
+// when none of the weighted inputs were measured.
+func (table ScoreTable) Score(input ScoreInput) (Score, bool) {
+	score := Score{TableVersion: table.Version, Input: input}
+	var total, weights float64
+	for _, component := range table.Components {
+		value := input.value(component.ID)
+		if value <= 0 {
+			continue
+		}
+		points := 100 * math.Log(value/component.Floor) / math.Log(component.Reference/component.Floor)
+		points = math.Round(math.Max(0, math.Min(100, points))*10) / 10
+		score.Components = append(score.Components, ComponentScore{ID: component.ID, Value: value, Score: points})
+		total += points * component.Weight
+		weights += component.Weight
+	}
+	if weights == 0 {
+		return Score{}, false
+	}
+	score.Score = math.Round(total/weights*10) / 10
+	class := table.classify(input)
+	score.Class, score.Label, score.LabelZh = class.ID, class.Label, class.LabelZh
+	return score, true
+}
+
+func (input ScoreInput) value(id string) float64 {
+	switch id {
+	case "latency_4k_qd1":
+		return float64(input.Latency4KQ1NS)
+	case "iops_4k_qd32":
+		return input.IOPS4KQ32
+	case "bandwidth_1m_seq":
+		return input.SequentialBPS
+	case "iops_4k_mixed":
+		return input.IOPS4KMixed
+	case "bandwidth_1m_mixed":
+		return input.MixedBPS
+	}
+	return 0
+}
+
+// classify 按顺序匹配类别；类别约束了未测得的输入时停止匹配并归为未分类
+func (table ScoreTable) classify(input ScoreInput) DeviceClass {
+	for _, class := range table.Classes {
+		if class.Throttled && !input.Throttled {
+			continue
+		}
+		for _, id := range class.boundInputs() {
+			if input.value(id) <= 0 {
+				return unclassifiedClass
+			}
+		}
+		if class.matches(input) {
+			return class
+		}
+	}
+	return unclassifiedClass
+}
+
+// boundInputs 返回类别设置了上下限的输入
+func (class DeviceClass) boundInputs() []string {
+	var inputs []string
+	for _, bound := range []struct {
+		id               string
+		minimum, maximum float64
+	}{
+		{"latency_4k_qd1", float64(class.MinLatencyNS), float64(class.MaxLatencyNS)},
+		{"iops_4k_qd32", class.MinIOPS, class.MaxIOPS},
+		{"bandwidth_1m_seq", class.MinBPS, class.MaxBPS},
+		{"iops_4k_mixed", class.MinMixedIOPS, class.MaxMixedIOPS},
+		{"bandwidth_1m_mixed", class.MinMixedBPS, class.MaxMixedBPS},
+	} {
+		if bound.minimum != 0 || bound.maximum != 0 {
+			inputs = append(inputs, bound.id)
+		}
+	}
+	return inputs
+}
+
+// matches 判断已测得的输入是否落在类别范围内
+func (class DeviceClass) matches(input ScoreInput) bool {
+	return withinBounds(float64(input.Latency4KQ1NS), float64(class.MinLatencyNS), float64(class.MaxLatencyNS)) &&
+		withinBounds(input.IOPS4KQ32, class.MinIOPS, class.MaxIOPS) &&
+		withinBounds(input.SequentialBPS, class.MinBPS, class.MaxBPS) &&
+		withinBounds(input.IOPS4KMixed, class.MinMixedIOPS, class.MaxMixedIOPS) &&
+		withinBounds(input.MixedBPS, class.MinMixedBPS, class.MaxMixedBPS)
+}
+
+func withinBounds(value, minimum, maximum float64) bool {
+	return (minimum == 0 || value >= minimum) && (maximum == 0 || value <= maximum)
+}
+
+// MatrixScoreInput extracts the score inputs from a structured result: the
+// 4k-q1-read median latency, the mean 4k-q32 read/write IOPS and the mean
+// 1m-q8 (or 1m-q1) read/write bandwidth.
+func MatrixScoreInput(result MatrixResult) ScoreInput {
+	var input ScoreInput
+	scenarios := make(map[string]FioMetrics, len(result.Metrics))
+	for _, metric := range result.Metrics {
+		scenarios[metric.ScenarioID] = metric
+	}
+	if metric, ok := scenarios["4k-q1-read"]; ok {
+		input.Latency4KQ1NS = metric.LatencyP50NS
+	}
+	input.IOPS4KQ32 = meanMetric(scenarios, []string{"4k-q32-read", "4k-q32-write"}, func(metric FioMetrics) float64 { return metric.IOPS })
+	bandwidth := func(metric FioMetrics) float64 { return float64(metric.BandwidthBytesPerSecond) }
+	if input.SequentialBPS = meanMetric(scenarios, []string{"1m-q8-read", "1m-q8-write"}, bandwidth); input.SequentialBPS == 0 {
+		input.SequentialBPS = meanMetric(scenarios, []string{"1m-q1-read", "1m-q1-write"}, bandwidth)
+	}
+	input.Throttled = result.Throttle != nil && len(result.Throttle.Warnings) > 0
+	return input
+}
+
+func meanMetric(scenarios map[string]FioMetrics, ids []string, value func(FioMetrics) float64) float64 {
+	var sum float64
+	var count int
+	for _, id := range ids {
+		if metric, ok := scenarios[id]; ok {
+			sum += value(metric)
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return sum / float64(count)
+}
+
+// legacyScores 根据传统FIO表格中4k与1m混合读写行的总IOPS和总带宽为每个路径评分
+func legacyScores(table ScoreTable, rows []FioBlockResult, throttles map[string]IOThrottle) map[string]Score {
+	inputs := make(map[string]ScoreInput)
+	for _, row := range rows {
+		input := inputs[row.Path]
+		switch strings.ToLower(row.BlockSize) {
+		case "4k":
+			input.IOPS4KMixed = row.TotalIOPS
+		case "1m":
+			input.MixedBPS = row.TotalBytesPerSecond
+		}
+		input.Throttled = len(throttles[row.Path].Warnings) > 0
+		inputs[row.Path] = input
+	}
+	var scores map[string]Score
+	for path, input := range inputs {
+		if score, ok := table.Score(input); ok {
+			if scores == nil {
+				scores = make(map[string]Score)
+			}
+			scores[path] = score
+		}
+	}
+	return scores
+}
+
+// renderScores 输出每个路径的评分与类别
+func renderScores(language string, scores map[string]Score) string {
+	paths := make([]string, 0, len(scores))
+	for path := range scores {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var text string
+	for _, path := range paths {
+		score := scores[path]
+		label := score.Label
+		if language != "en" && score.LabelZh != "" {
+			label = score.LabelZh
+		}
+		text += localizedText(language, fmt.Sprintf("%s 磁盘评分: %.1f/100 (%s)", path, score.Score, label),
+			fmt.Sprintf("Disk score for %s: %.1f/100 (%s)", path, score.Score, label)) + "\n"
+	}
+	return text
+}
@@ -0,0 +1,105 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultScoreTableClasses(t *testing.T) {
+	table := DefaultScoreTable()
+	if err := table.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		input ScoreInput
+		class string
+	}{
+		{ScoreInput{Latency4KQ1NS: 80_000, IOPS4KQ32: 400_000, SequentialBPS: 3_000_000_000}, "nvme"},
+		{ScoreInput{Latency4KQ1NS: 200_000, IOPS4KQ32: 60_000, SequentialBPS: 500_000_000}, "sata-ssd"},
+		{ScoreInput{Latency4KQ1NS: 8_000_000, IOPS4KQ32: 300, SequentialBPS: 180_000_000}, "hdd"},
+		{ScoreInput{Latency4KQ1NS: 900_000, IOPS4KQ32: 3000, SequentialBPS: 125_000_000}, "network-throttled"},
+		{ScoreInput{Latency4KQ1NS: 80_000, IOPS4KQ32: 400_000, SequentialBPS: 3_000_000_000, Throttled: true}, "cgroup-throttled"},
+		// 缺少延迟时不能借用更快类别的标签
+		{ScoreInput{IOPS4KQ32: 150_000, SequentialBPS: 2_000_000_000}, "unclassified"},
+		{ScoreInput{SequentialBPS: 2_000_000_000}, "unclassified"},
+	} {
+		score, ok := table.Score(test.input)
+		if !ok || score.Class != test.class {
+			t.Fatalf("Score(%+v) = %+v, want class %s", test.input, score, test.class)
+		}
+		if score.Score < 0 || score.Score > 100 || score.TableVersion != "disk-score/v1" {
+			t.Fatalf("unexpected score %+v", score)
+		}
+	}
+	if _, ok := table.Score(ScoreInput{}); ok {
+		t.Fatal("empty input was scored")
+	}
+	top, _ := table.Score(ScoreInput{Latency4KQ1NS: 10_000, IOPS4KQ32: 1_000_000, SequentialBPS: 10_000_000_000})
+	if top.Score != 100 {
+		t.Fatalf("inputs beyond the reference scored %.1f", top.Score)
+	}
+}
+
+func TestLoadScoreTable(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "table.json")
+	os.WriteFile(valid, []byte(`{"version":"site/2","components":[{"id":"iops_4k_qd32","weight":1,"reference":10000,"floor":10}],"classes":[{"id":"any","label":"Any"}]}`), 0o644)
+	table, err := LoadScoreTable(valid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	score, ok := table.Score(ScoreInput{IOPS4KQ32: 10000, SequentialBPS: 1})
+	if !ok || score.Score != 100 || score.Class != "any" || len(score.Components) != 1 {
+		t.Fatalf("unexpected score %+v", score)
+	}
+	for name, content := range map[string]string{
+		"component.json": `{"version":"x","components":[{"id":"seq","weight":1,"reference":2,"floor":1}],"classes":[{"id":"a","label":"A"}]}`,
+		"floor.json":     `{"version":"x","components":[{"id":"iops_4k_qd32","weight":1,"reference":2,"floor":2}],"classes":[{"id":"a","label":"A"}]}`,
+		"classes.json":   `{"version":"x","components":[{"id":"iops_4k_qd32","weight":1,"reference":2,"floor":1}]}`,
+	} {
+		invalid := filepath.Join(dir, name)
+		os.WriteFile(invalid, []byte(content), 0o644)
+		if _, err := LoadScoreTable(invalid); err == nil {
+			t.Fatalf("LoadScoreTable accepted %s", name)
+		}
+	}
+}
+
+func TestMatrixAndLegacyScoreInputs(t *testing.T) {
+	input := MatrixScoreInput(MatrixResult{Metrics: []FioMetrics{
+		{ScenarioID: "4k-q1-read", Direction: "read", LatencyP50NS: 90_000},
+		{ScenarioID: "4k-q32-read", Direction: "read", IOPS: 300_000},
+		{ScenarioID: "4k-q32-write", Direction: "write", IOPS: 100_000},
+		{ScenarioID: "1m-q1-read", Direction: "read", BandwidthBytesPerSecond: 1_000_000_000},
+	}})
+	if input.Latency4KQ1NS != 90_000 || input.IOPS4KQ32 != 200_000 || input.SequentialBPS != 1_000_000_000 {
+		t.Fatalf("unexpected input %+v", input)
+	}
+	if err := DefaultLegacyScoreTable().ValidateLegacy(); err != nil {
+		t.Fatal(err)
+	}
+	if err := DefaultScoreTable().ValidateMatrix(); err != nil {
+		t.Fatal(err)
+	}
+	// 评分表只能使用当前模式测得的输入
+	if DefaultScoreTable().ValidateLegacy() == nil || DefaultLegacyScoreTable().ValidateMatrix() == nil {
+		t.Fatal("a score table was accepted for the other mode")
+	}
+	scores := legacyScores(DefaultLegacyScoreTable(), []FioBlockResult{
+		{Path: "/data", BlockSize: "4k", TotalIOPS: 50_000},
+		{Path: "/data", BlockSize: "64k", TotalIOPS: 9_000},
+		{Path: "/data", BlockSize: "1m", TotalBytesPerSecond: 600_000_000},
+	}, nil)
+	if score := scores["/data"]; score.Class != "sata-ssd" || score.TableVersion != "disk-score-legacy/v2" || score.Input.IOPS4KMixed != 50_000 || score.Input.IOPS4KQ32 != 0 {
+		t.Fatalf("unexpected legacy scores %+v", scores)
+	}
+	// 自定义块大小缺少1m行时，约束带宽的类别无法判断
+	partial := legacyScores(DefaultLegacyScoreTable(), []FioBlockResult{{Path: "/data", BlockSize: "4k", TotalIOPS: 300_000}}, nil)
+	if score := partial["/data"]; score.Class != "unclassified" {
+		t.Fatalf("partial legacy input was classified: %+v", score)
+	}
+	if text := renderScores("en", scores); text != "Disk score for /data: 67.8/100 (SATA SSD)\n" {
+		t.Fatalf("unexpected rendering %q", text)
+	}
+}
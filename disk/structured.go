@@ -41,19 +41,68 @@ type MatrixConfig struct {
 	SizeBytes   int64
 	Runtime     time.Duration
 	MaxDuration time.Duration
+	// DataPattern selects the written buffers; the zero value keeps fio's
+	// default buffers.
+	DataPattern DataPattern
+	// ScanMembers reads the first MemberScanBytes of every physical disk
+	// under Path after the matrix, read-only. It needs AllowDevice because
+	// the members are raw block devices.
+	ScanMembers     bool
+	AllowDevice     bool
+	MemberScanBytes int64
+	// ScoreTable replaces DefaultScoreTable when scoring the result.
+	ScoreTable *ScoreTable
+	// DryRun fills MatrixResult.Plan with the commands the matrix would
+	// run, without creating the test file or acquiring fio.
+	DryRun bool
+	// FioPath selects the fio binary instead of the system or embedded
+	// one; empty falls back to the DISKTEST_FIO environment variable.
+	FioPath string
+	// Units is the unit system recorded in MatrixResult and used by the
+	// table rendering; the zero value means SI.
+	Units UnitFormat
+}
+
+// MemberScanResult is the read-only surface scan of one physical disk under
+// the matrix path.
+type MemberScanResult struct {
+	Device string `json:"device"`
+	ScanResult
 }
 
 type MatrixResult struct {
 	SchemaVersion string       `json:"schema_version"`
 	Status        string       `json:"status"`
+	Path          string       `json:"path,omitempty"`
+	IOEngine      string       `json:"io_engine,omitempty"`
+	DataPattern   string       `json:"data_pattern,omitempty"`
 	Metrics       []FioMetrics `json:"metrics,omitempty"`
-	DurationMS    int64        `json:"duration_ms"`
-	Error         string       `json:"error,omitempty"`
+	// Scenarios lists the configured scenario IDs in run order, including
+	// the ones that did not complete.
+	Scenarios  []string `json:"scenarios,omitempty"`
+	DurationMS int64    `json:"duration_ms"`
+	Error      string   `json:"error,omitempty"`
+	// Stack is the block-device stack below Path, when resolved.
+	Stack   *StorageStack      `json:"stack,omitempty"`
+	Members []MemberScanResult `json:"members,omitempty"`
+	// Throttle is the cgroup v2 I/O configuration limiting Path, with
+	// warnings for metrics close to a limit.
+	Throttle *IOThrottle `json:"throttle,omitempty"`
+	// Score rates the 4k and 1m metrics against a ScoreTable.
+	Score *Score `json:"score,omitempty"`
+	// Plan is the resolved workload of a dry run.
+	Plan *TestPlan `json:"plan,omitempty"`
+	// Fio is the fio binary the matrix ran with.
+	Fio *FioInfo `json:"fio,omitempty"`
+	// Units is the unit system the human-readable output uses; bandwidth
+	// values in the JSON are always bytes per second.
+	Units UnitFormat `json:"units"`
 }
 
 type fioAcquisition struct {
 	Command []string
 	Cleanup func() error
+	Info    FioInfo
 }
 
 type fioProvider func(context.Context) (fioAcquisition, error)
@@ -155,7 +204,7 @@ func RunDeepFioMatrix(ctx context.Context, config MatrixConfig) (result MatrixRe
 }
 
 func runFioMatrix(ctx context.Context, config MatrixConfig, scenarios []FioScenario, maximumDuration time.Duration) (result MatrixResult) {
-	return runFioMatrixWithProvider(ctx, config, scenarios, maximumDuration, findFIO)
+	return runFioMatrixWithProvider(ctx, config, scenarios, maximumDuration, matrixFioProvider(config.FioPath))
 }
 
 func runFioMatrixWithProvider(ctx context.Context, config MatrixConfig, scenarios []FioScenario, maximumDuration time.Duration, provider fioProvider) (result MatrixResult) {
@@ -188,7 +237,7 @@ func runFioMatrixWithDeps(ctx context.Context, config MatrixConfig, scenarios []
 		config.Runtime = 10 * time.Second
 	}
 	if len(scenarios) == 0 {
-		return MatrixResult{SchemaVersion: "goecs.disk/v1", Status: "unavailable", Error: "fio scenario list is empty"}
+		return MatrixResult{SchemaVersion: "goecs.disk/v1", Status: "unavailable", Error: "fio scenario list is empty", Units: config.Units.resolved()}
 	}
 	if maximumDuration <= 0 {
 		maximumDuration = 60 * time.Second
@@ -196,9 +245,55 @@ func runFioMatrixWithDeps(ctx context.Context, config MatrixConfig, scenarios []
 	if config.MaxDuration <= 0 || config.MaxDuration > maximumDuration {
 		config.MaxDuration = maximumDuration
 	}
-	result = MatrixResult{SchemaVersion: "goecs.disk/v1", Status: "ok"}
+	result = MatrixResult{SchemaVersion: "goecs.disk/v1", Status: "ok", Path: config.Path, DataPattern: config.DataPattern.String(), Units: config.Units.resolved()}
+	for _, scenario := range scenarios {
+		result.Scenarios = append(result.Scenarios, scenario.ID)
+	}
 	started := time.Now()
 	defer func() { result.DurationMS = time.Since(started).Milliseconds() }()
+	if err := config.DataPattern.validate(); err != nil {
+		result.Status, result.Error = "unavailable", "invalid_data_pattern"
+		return result
+	}
+	scoreTable := DefaultScoreTable()
+	if config.ScoreTable != nil {
+		if err := config.ScoreTable.ValidateMatrix(); err != nil {
+			result.Status, result.Error = "unavailable", "invalid_score_table"
+			return result
+		}
+		scoreTable = *config.ScoreTable
+	}
+	defer func() {
+		if score, ok := scoreTable.Score(MatrixScoreInput(result)); ok {
+			result.Score = &score
+		}
+	}()
+	if stack, err := ResolveStorageStack(config.Path); err == nil && len(stack.Layers) > 0 {
+		result.Stack = &stack
+	}
+	if throttle, ok := DetectIOThrottle(config.Path); ok {
+		result.Throttle = &throttle
+		defer func() {
+			for _, metric := range result.Metrics {
+				result.Throttle.check(metric.Direction, float64(metric.BandwidthBytesPerSecond), metric.IOPS)
+			}
+		}()
+	}
+	if config.DryRun {
+		plan := planMatrix(config, scenarios)
+		result.Status, result.Plan = "planned", &plan
+		if plan.Error != "" {
+			result.Status, result.Error = "unavailable", plan.Error
+		}
+		return result
+	}
+	if config.ScanMembers && config.AllowDevice && result.Stack != nil {
+		defer func() {
+			if result.Status == "ok" {
+				result.Members = scanStackMembers(ctx, *result.Stack, config.MemberScanBytes)
+			}
+		}()
+	}
 	matrixCtx, cancel := context.WithTimeout(ctx, config.MaxDuration)
 	defer cancel()
 	if err := matrixCtx.Err(); err != nil {
@@ -215,8 +310,9 @@ func runFioMatrixWithDeps(ctx context.Context, config MatrixConfig, scenarios []
 		return result
 	}
 	testPath := testFile.Name()
+	markArtifact(testPath)
 	_ = testFile.Close()
-	defer os.Remove(testPath)
+	defer removeArtifact(testPath)
 	acquired, err := provider(matrixCtx)
 	if acquired.Cleanup != nil {
 		defer func() { _ = acquired.Cleanup() }()
@@ -239,22 +335,16 @@ func runFioMatrixWithDeps(ctx context.Context, config MatrixConfig, scenarios []
 	if perScenarioRuntime > maximumPerScenario {
 		perScenarioRuntime = maximumPerScenario
 	}
-	ioEngine := selectMatrixIOEngine(matrixCtx, acquired.Command, config.Path, runner)
+	result.Fio = &acquired.Info
+	ioEngine := selectMatrixIOEngine(matrixCtx, acquired.Command, acquired.Info, config.Path, runner)
+	result.IOEngine = ioEngine
 	for _, scenario := range scenarios {
 		if err := matrixCtx.Err(); err != nil {
 			result.Status, result.Error = matrixStopStatus(err), stableMatrixError(err)
 			return result
 		}
 		command := append([]string{}, acquired.Command...)
-		args := make([]string, 0, 12)
-		args = append(args,
-			"--name="+scenario.ID, "--ioengine="+ioEngine, "--rw="+scenario.RW,
-			"--bs="+scenario.BlockSize, fmt.Sprintf("--iodepth=%d", scenario.QueueDepth),
-			fmt.Sprintf("--numjobs=%d", scenario.Jobs), fmt.Sprintf("--size=%d", config.SizeBytes),
-			fmt.Sprintf("--runtime=%d", max(int(perScenarioRuntime.Seconds()), 1)), "--time_based=1",
-			"--direct=1", "--filename="+testPath, "--group_reporting=1", "--output-format=json",
-		)
-		command = append(command, args...)
+		command = append(command, matrixFioArgs(scenario, ioEngine, testPath, perScenarioRuntime, config)...)
 		output, runErr := runner(matrixCtx, command)
 		if runErr != nil {
 			if matrixCtx.Err() != nil {
@@ -274,6 +364,36 @@ func runFioMatrixWithDeps(ctx context.Context, config MatrixConfig, scenarios []
 	return result
 }
 
+// matrixFioArgs 生成结构化矩阵单个场景的fio参数
+func matrixFioArgs(scenario FioScenario, ioEngine, testPath string, runtime time.Duration, config MatrixConfig) []string {
+	runtimeSeconds := max(int(runtime.Seconds()), 1)
+	args := make([]string, 0, 16)
+	args = append(args,
+		"--name="+scenario.ID, "--ioengine="+ioEngine, "--rw="+scenario.RW,
+		"--bs="+scenario.BlockSize, fmt.Sprintf("--iodepth=%d", scenario.QueueDepth),
+		fmt.Sprintf("--numjobs=%d", scenario.Jobs), fmt.Sprintf("--size=%d", config.SizeBytes),
+		fmt.Sprintf("--runtime=%d", runtimeSeconds), "--time_based=1",
+		"--direct=1", "--filename="+testPath, "--group_reporting=1", "--output-format=json",
+	)
+	return append(args, config.DataPattern.fioArgs()...)
+}
+
+// scanStackMembers 只读扫描存储栈底层的每块物理磁盘
+func scanStackMembers(ctx context.Context, stack StorageStack, maxBytes int64) []MemberScanResult {
+	if maxBytes <= 0 {
+		maxBytes = 256 << 20
+	}
+	var members []MemberScanResult
+	for _, device := range stack.Members() {
+		if ctx.Err() != nil {
+			break
+		}
+		scan := RunSurfaceScan(ctx, ScanConfig{Path: device, MaxBytes: maxBytes, AllowDevice: true, MapBuckets: 8, MaxDuration: 15 * time.Second})
+		members = append(members, MemberScanResult{Device: device, ScanResult: scan})
+	}
+	return members
+}
+
 var getEmbeddedFIO = embeddedfio.GetFIO
 var cleanEmbeddedFIO = embeddedfio.CleanFio
 
@@ -283,6 +403,13 @@ func findFIO(ctx context.Context) (fioAcquisition, error) {
 	} else if ctx.Err() != nil {
 		return fioAcquisition{}, ctx.Err()
 	}
+	return findEmbeddedFIO(ctx)
+}
+
+func findEmbeddedFIO(ctx context.Context) (fioAcquisition, error) {
+	if err := ctx.Err(); err != nil {
+		return fioAcquisition{}, err
+	}
 	command, temporaryPath, err := getEmbeddedFIO()
 	if err != nil {
 		return fioAcquisition{}, fmt.Errorf("embedded fio is unavailable: %w", err)
@@ -293,14 +420,15 @@ func findFIO(ctx context.Context) (fioAcquisition, error) {
 		_ = cleanup()
 		return fioAcquisition{}, errors.New("embedded fio command is empty")
 	}
-	if _, err := runFIOCommand(ctx, append(append([]string(nil), parts...), "--help")); err != nil {
+	info, err := inspectFio(ctx, parts, FioInfo{Path: temporaryPath, Source: "embedded"})
+	if err != nil {
 		_ = cleanup()
 		if ctx.Err() != nil {
 			return fioAcquisition{}, ctx.Err()
 		}
 		return fioAcquisition{}, fmt.Errorf("embedded fio probe failed: %w", err)
 	}
-	return fioAcquisition{Command: parts, Cleanup: cleanup}, nil
+	return fioAcquisition{Command: parts, Cleanup: cleanup, Info: info}, nil
 }
 
 func findSystemFIO(ctx context.Context) (fioAcquisition, error) {
@@ -311,20 +439,21 @@ func findSystemFIO(ctx context.Context) (fioAcquisition, error) {
 	if err != nil {
 		return fioAcquisition{}, fmt.Errorf("system fio is unavailable: %w", err)
 	}
-	if _, err := runFIOCommand(ctx, []string{path, "--help"}); err != nil {
+	info, err := inspectFio(ctx, []string{path}, FioInfo{Path: path, Source: "system"})
+	if err != nil {
 		if ctx.Err() != nil {
 			return fioAcquisition{}, ctx.Err()
 		}
 		return fioAcquisition{}, fmt.Errorf("system fio probe failed: %w", err)
 	}
-	return fioAcquisition{Command: []string{path}}, nil
+	return fioAcquisition{Command: []string{path}, Info: info}, nil
 }
 
 func runFIOCommand(ctx context.Context, command []string) ([]byte, error) {
 	if len(command) == 0 || strings.TrimSpace(command[0]) == "" {
 		return nil, errors.New("fio command is empty")
 	}
-	return exec.CommandContext(ctx, command[0], command[1:]...).Output()
+	return benchmarkCommand(ctx, command[0], command[1:]...).Output()
 }
 
 func matrixStopStatus(err error) string {
@@ -366,11 +495,14 @@ func stableTestPathError(err error) string {
 	}
 }
 
-func selectMatrixIOEngine(ctx context.Context, commandParts []string, directory string, runner fioCommandRunner) string {
+func selectMatrixIOEngine(ctx context.Context, commandParts []string, info FioInfo, directory string, runner fioCommandRunner) string {
 	engines := []string{"psync"}
 	switch runtime.GOOS {
 	case "linux":
-		engines = []string{"io_uring", "libaio", "posixaio", "psync"}
+		engines = []string{"libaio", "posixaio", "psync"}
+		if info.mayUseIOUring() {
+			engines = append([]string{"io_uring"}, engines...)
+		}
 	case "darwin", "freebsd":
 		engines = []string{"posixaio", "psync"}
 	case "windows":
@@ -381,8 +513,9 @@ func selectMatrixIOEngine(ctx context.Context, commandParts []string, directory
 		return "psync"
 	}
 	probePath := probe.Name()
+	markArtifact(probePath)
 	probe.Close()
-	defer os.Remove(probePath)
+	defer removeArtifact(probePath)
 	for _, engine := range engines {
 		if ctx.Err() != nil {
 			return "psync"
@@ -398,6 +531,10 @@ func selectMatrixIOEngine(ctx context.Context, commandParts []string, directory
 	return "psync"
 }
 
+// matrixSpaceReserve is the free space every temporary test file must leave
+// untouched on the target filesystem.
+const matrixSpaceReserve = uint64(512 << 20)
+
 func ensureMatrixSpace(path string, requested int64) error {
 	info, err := os.Stat(path)
 	if err != nil {
@@ -411,8 +548,9 @@ func ensureMatrixSpace(path string, requested int64) error {
 		return err
 	}
 	probeName := probe.Name()
+	markArtifact(probeName)
 	probe.Close()
-	os.Remove(probeName)
+	removeArtifact(probeName)
 	if requested < 16<<20 {
 		return errors.New("fio size must be at least 16 MiB")
 	}
@@ -423,8 +561,7 @@ func ensureMatrixSpace(path string, requested int64) error {
 	if err != nil {
 		return err
 	}
-	reserve := uint64(512 << 20)
-	if usage.Free <= uint64(requested)+reserve {
+	if usage.Free <= uint64(requested)+matrixSpaceReserve {
 		return errors.New("insufficient free space for fio test and safety reserve")
 	}
 	return nil
@@ -0,0 +1,168 @@
+package disk
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// RenderMatrixTable renders a structured matrix as an aligned, localized
+// table with human units, the same text as the "table" report format.
+func RenderMatrixTable(language string, result MatrixResult) string {
+	report := MatrixReport(result)
+	report.Language = language
+	return renderTableReport(report)
+}
+
+// RenderMultiPathTable renders a multi-path matrix with one table per path.
+func RenderMultiPathTable(language string, result MultiPathResult) string {
+	report := MultiPathReport(result)
+	report.Language = language
+	return renderTableReport(report)
+}
+
+func writeTableReport(w io.Writer, report Report) error {
+	_, err := io.WriteString(w, renderTableReport(report))
+	return err
+}
+
+// tablePath 按路径汇总的矩阵行与状态
+type tablePath struct {
+	path, engine, status, err string
+	rows                      [][]string
+}
+
+// renderTableReport 矩阵报告按路径分组并换算为易读单位，其他报告按列对齐输出
+func renderTableReport(report Report) string {
+	if !slices.Equal(report.Columns, matrixReportColumns) {
+		return renderAlignedRows(report.Columns, humanizeReportRows(report)) + " " + renderUnitNote(report.Language, report.Units)
+	}
+	language := report.Language
+	var groups []*tablePath
+	group := func(path string) *tablePath {
+		for _, existing := range groups {
+			if existing.path == path {
+				return existing
+			}
+		}
+		groups = append(groups, &tablePath{path: path, status: "ok"})
+		return groups[len(groups)-1]
+	}
+	for _, metric := range report.Metrics {
+		current := group(metric.Path)
+		current.engine = metric.Engine
+		direction := localizedText(language, "读", "read")
+		if metric.Direction == "write" {
+			direction = localizedText(language, "写", "write")
+		}
+		current.rows = append(current.rows, []string{
+			metric.ScenarioID, direction,
+			report.Units.Rate(float64(metric.BandwidthBytesPerSecond)),
+			formatIOPS(int(math.Round(metric.IOPS)), "int"),
+			formatLatency(metric.LatencyP50NS), formatLatency(metric.LatencyP95NS), formatLatency(metric.LatencyP99NS),
+		})
+	}
+	for _, row := range report.Rows {
+		if len(row) == len(matrixReportColumns) && row[1] == "" {
+			current := group(row[0])
+			current.status, current.err = row[8], row[9]
+		}
+	}
+	header := []string{
+		localizedText(language, "场景", "Scenario"), localizedText(language, "方向", "Direction"),
+		localizedText(language, "带宽", "Bandwidth"), "IOPS", "P50", "P95", "P99",
+	}
+	var text strings.Builder
+	for index, current := range groups {
+		if index > 0 {
+			text.WriteString("\n")
+		}
+		line := localizedText(language, "测试路径: ", "Test Path: ") + current.path
+		if current.engine != "" {
+			line += "    " + localizedText(language, "IO引擎: ", "IO Engine: ") + current.engine
+		}
+		line += "    " + localizedText(language, "状态: ", "Status: ") + current.status
+		if current.err != "" {
+			line += "    " + localizedText(language, "错误: ", "Error: ") + current.err
+		}
+		text.WriteString(" " + line + "\n")
+		if len(current.rows) > 0 {
+			text.WriteString(renderAlignedRows(header, current.rows))
+		}
+	}
+	if len(groups) > 1 || len(groups) == 0 {
+		text.WriteString(" " + localizedText(language, "总体状态: ", "Overall Status: ") + report.Status + "\n")
+	}
+	text.WriteString(" " + renderUnitNote(language, report.Units))
+	return text.String()
+}
+
+// humanizeReportRows 将带宽与IOPS列从原始数值换算为易读单位，其余单元格保持不变
+func humanizeReportRows(report Report) [][]string {
+	rows := make([][]string, len(report.Rows))
+	for index, row := range report.Rows {
+		rows[index] = slices.Clone(row)
+		for column, cell := range row {
+			if column >= len(report.Columns) || cell == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				continue
+			}
+			switch name := report.Columns[column]; {
+			case strings.HasSuffix(name, "_bytes_per_second"):
+				rows[index][column] = report.Units.Rate(value)
+			case strings.HasSuffix(name, "_iops"):
+				rows[index][column] = formatIOPS(int(math.Round(value)), "int")
+			}
+		}
+	}
+	return rows
+}
+
+// renderAlignedRows 按显示宽度对齐各列，中文等宽字符按两列计算
+func renderAlignedRows(header []string, rows [][]string) string {
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for column, cell := range row {
+			if column >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[column] = max(widths[column], runewidth.StringWidth(cell))
+		}
+	}
+	var text strings.Builder
+	for _, row := range append([][]string{header}, rows...) {
+		cells := make([]string, len(row))
+		for column, cell := range row {
+			if column == len(row)-1 {
+				cells[column] = cell
+			} else {
+				cells[column] = runewidth.FillRight(cell, widths[column])
+			}
+		}
+		text.WriteString(" " + strings.Join(cells, "   ") + "\n")
+	}
+	return text.String()
+}
+
+// formatLatency 将纳秒延迟换算为 ns、µs、ms 或 s
+func formatLatency(ns uint64) string {
+	switch {
+	case ns == 0:
+		return "-"
+	case ns < 1_000:
+		return fmt.Sprintf("%dns", ns)
+	case ns < 1_000_000:
+		return fmt.Sprintf("%.1fµs", float64(ns)/1e3)
+	case ns < 1_000_000_000:
+		return fmt.Sprintf("%.2fms", float64(ns)/1e6)
+	}
+	return fmt.Sprintf("%.2fs", float64(ns)/1e9)
+}
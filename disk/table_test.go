@@ -0,0 +1,43 @@
+package disk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+)
+
+func TestRenderMatrixTable(t *testing.T) {
+	text := RenderMatrixTable("en", fixtureMatrixResult())
+	for _, want := range []string{
+		" Test Path: /data    Status: error    Error: fio_failed\n",
+		" 4k-q1-read    read        4.10 MB/s   1000   90.0µs   -     -\n",
+		" 1m-q8-write   write       1.07 GB/s   1024   -        -     -\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("table missing %q:\n%s", want, text)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(RenderMatrixTable("zh", fixtureMatrixResult()), "\n"), "\n")
+	if !strings.HasPrefix(lines[1], " 场景  ") || runewidth.StringWidth(strings.Split(lines[1], "方向")[0]) != runewidth.StringWidth(strings.Split(lines[2], "读")[0]) {
+		t.Fatalf("columns are not aligned by display width:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestRenderMultiPathTable(t *testing.T) {
+	result := MultiPathResult{SchemaVersion: "goecs.disk/multi-v1", Status: "partial", Paths: []MatrixResult{
+		{Status: "ok", Path: "/a", IOEngine: "libaio", Metrics: []FioMetrics{{ScenarioID: "4k-q1-read", Direction: "read", LatencyP50NS: 2_500_000}}},
+		{Status: "unavailable", Path: "/b", Error: "insufficient_space"},
+	}}
+	text := RenderMultiPathTable("en", result)
+	for _, want := range []string{"Test Path: /a    IO Engine: libaio    Status: ok", "2.50ms", "Test Path: /b    Status: unavailable    Error: insufficient_space", "Overall Status: partial"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("table missing %q:\n%s", want, text)
+		}
+	}
+	var output bytes.Buffer
+	if err := WriteReport(&output, "table", LegacyReport("disktest dd", " Path   Speed\n /a     1 GB/s\n")); err != nil || output.String() != " Path   Speed\n /a     1 GB/s\n 单位制: SI\n" {
+		t.Fatalf("unexpected legacy table %q err=%v", output.String(), err)
+	}
+}
@@ -0,0 +1,221 @@
+package disk
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// throttleNearRatio is how close to a cgroup limit a measurement has to be
+// before it is reported as probably measuring the limit.
+const throttleNearRatio = 0.9
+
+// IOThrottle is the cgroup v2 I/O configuration that applies to a test
+// device. Limits are the tightest io.max values along the cgroup hierarchy
+// and zero means max; Weight is only set when it differs from the default
+// 100, and LatencyTargetUS is the io.latency target in microseconds.
+type IOThrottle struct {
+	Cgroup          string `json:"cgroup"`
+	Device          string `json:"device"`
+	ReadBPS         uint64 `json:"read_bps_limit,omitempty"`
+	WriteBPS        uint64 `json:"write_bps_limit,omitempty"`
+	ReadIOPS        uint64 `json:"read_iops_limit,omitempty"`
+	WriteIOPS       uint64 `json:"write_iops_limit,omitempty"`
+	Weight          uint64 `json:"weight,omitempty"`
+	LatencyTargetUS uint64 `json:"latency_target_us,omitempty"`
+	// Warnings lists measurements near a limit, such as
+	// write_bps_near_limit.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// DetectIOThrottle returns the cgroup v2 I/O settings of the current process
+// for the device holding path. It reports false when the process is not in
+// a cgroup v2 hierarchy or nothing is configured for the device.
+func DetectIOThrottle(path string) (IOThrottle, bool) {
+	throttle, ok := detectIOThrottle(path)
+	if !ok || !throttle.configured() {
+		return IOThrottle{}, false
+	}
+	return throttle, true
+}
+
+func (throttle IOThrottle) configured() bool {
+	return throttle.ReadBPS != 0 || throttle.WriteBPS != 0 || throttle.ReadIOPS != 0 || throttle.WriteIOPS != 0 ||
+		throttle.Weight != 0 || throttle.LatencyTargetUS != 0
+}
+
+// check 测量值达到限制的90%以上时记录警告
+func (throttle *IOThrottle) check(direction string, bytesPerSecond, iops float64) {
+	bpsLimit, iopsLimit := throttle.ReadBPS, throttle.ReadIOPS
+	if direction == "write" {
+		bpsLimit, iopsLimit = throttle.WriteBPS, throttle.WriteIOPS
+	}
+	if bpsLimit != 0 && bytesPerSecond >= float64(bpsLimit)*throttleNearRatio {
+		throttle.warn(direction + "_bps_near_limit")
+	}
+	if iopsLimit != 0 && iops >= float64(iopsLimit)*throttleNearRatio {
+		throttle.warn(direction + "_iops_near_limit")
+	}
+}
+
+func (throttle *IOThrottle) warn(code string) {
+	for _, existing := range throttle.Warnings {
+		if existing == code {
+			return
+		}
+	}
+	throttle.Warnings = append(throttle.Warnings, code)
+}
+
+// parseIOMax 解析 io.max 中匹配设备的一行，max 记为0
+func parseIOMax(content string, devices []string) (rbps, wbps, riops, wiops uint64) {
+	for _, fields := range deviceLines(content, devices) {
+		for _, field := range fields {
+			key, value, _ := strings.Cut(field, "=")
+			limit, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "rbps":
+				rbps = limit
+			case "wbps":
+				wbps = limit
+			case "riops":
+				riops = limit
+			case "wiops":
+				wiops = limit
+			}
+		}
+	}
+	return rbps, wbps, riops, wiops
+}
+
+// parseIOWeight 解析 io.weight，设备单独配置优先于 default
+func parseIOWeight(content string, devices []string) uint64 {
+	var weight uint64
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "default" {
+			weight, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	for _, fields := range deviceLines(content, devices) {
+		if len(fields) == 1 {
+			weight, _ = strconv.ParseUint(fields[0], 10, 64)
+		}
+	}
+	if weight == 100 {
+		return 0
+	}
+	return weight
+}
+
+// parseIOLatency 解析 io.latency 中的 target，单位微秒
+func parseIOLatency(content string, devices []string) uint64 {
+	var target uint64
+	for _, fields := range deviceLines(content, devices) {
+		for _, field := range fields {
+			if value, ok := strings.CutPrefix(field, "target="); ok {
+				target, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+	return target
+}
+
+// deviceLines 返回以任一设备号开头的行的其余字段
+func deviceLines(content string, devices []string) [][]string {
+	var lines [][]string
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, device := range devices {
+			if fields[0] == device {
+				lines = append(lines, fields[1:])
+				break
+			}
+		}
+	}
+	return lines
+}
+
+// minLimit 合并层级中的限制，0表示不限制
+func minLimit(current, limit uint64) uint64 {
+	if current == 0 || (limit != 0 && limit < current) {
+		return limit
+	}
+	return current
+}
+
+// detectThrottles 返回测试路径上生效的cgroup I/O配置
+func detectThrottles(paths []string) map[string]IOThrottle {
+	var throttles map[string]IOThrottle
+	for _, path := range paths {
+		if throttle, ok := DetectIOThrottle(path); ok {
+			if throttles == nil {
+				throttles = make(map[string]IOThrottle)
+			}
+			throttles[path] = throttle
+		}
+	}
+	return throttles
+}
+
+// checkThrottle 按路径检查测量值是否接近cgroup限制
+func checkThrottle(throttles map[string]IOThrottle, path, direction string, bytesPerSecond, iops float64) {
+	if throttle, ok := throttles[path]; ok {
+		throttle.check(direction, bytesPerSecond, iops)
+		throttles[path] = throttle
+	}
+}
+
+// renderThrottles 输出cgroup I/O配置以及接近限制的警告
+func renderThrottles(language string, units UnitFormat, throttles map[string]IOThrottle) string {
+	paths := make([]string, 0, len(throttles))
+	for path := range throttles {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var text string
+	for _, path := range paths {
+		throttle := throttles[path]
+		text += localizedText(language, path+" 的cgroup I/O限制: ", "cgroup I/O limits on "+path+": ") + throttle.summary(units) + "\n"
+		for _, warning := range throttle.Warnings {
+			direction := localizedText(language, "读取", "read")
+			if strings.HasPrefix(warning, "write") {
+				direction = localizedText(language, "写入", "write")
+			}
+			text += localizedText(language, "警告: "+direction+"结果接近cgroup限制，测得的可能是限制而非磁盘性能",
+				"Warning: the "+direction+" result is close to the cgroup limit and likely measures the limit, not the disk") + "\n"
+		}
+	}
+	return text
+}
+
+// summary 生成形如 "rbps=100.00 MB/s wiops=500 weight=50" 的摘要
+func (throttle IOThrottle) summary(units UnitFormat) string {
+	var parts []string
+	if throttle.ReadBPS != 0 {
+		parts = append(parts, "rbps="+units.Rate(float64(throttle.ReadBPS)))
+	}
+	if throttle.WriteBPS != 0 {
+		parts = append(parts, "wbps="+units.Rate(float64(throttle.WriteBPS)))
+	}
+	if throttle.ReadIOPS != 0 {
+		parts = append(parts, fmt.Sprintf("riops=%d", throttle.ReadIOPS))
+	}
+	if throttle.WriteIOPS != 0 {
+		parts = append(parts, fmt.Sprintf("wiops=%d", throttle.WriteIOPS))
+	}
+	if throttle.Weight != 0 {
+		parts = append(parts, fmt.Sprintf("weight=%d", throttle.Weight))
+	}
+	if throttle.LatencyTargetUS != 0 {
+		parts = append(parts, fmt.Sprintf("latency_target=%dus", throttle.LatencyTargetUS))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,87 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cgroupRoot 与 procSelfCgroup 测试时可替换
+var (
+	cgroupRoot     = "/sys/fs/cgroup"
+	procSelfCgroup = "/proc/self/cgroup"
+)
+
+// detectIOThrottle 从当前cgroup向上逐级读取 io.max、io.weight 与 io.latency
+func detectIOThrottle(path string) (IOThrottle, bool) {
+	content, err := os.ReadFile(procSelfCgroup)
+	if err != nil {
+		return IOThrottle{}, false
+	}
+	var group string
+	for _, line := range strings.Split(string(content), "\n") {
+		if value, ok := strings.CutPrefix(line, "0::"); ok {
+			group = value
+		}
+	}
+	if group == "" {
+		return IOThrottle{}, false
+	}
+	deviceID, name := mountBlockDevice(path, "")
+	devices := throttleDevices(deviceID, name)
+	if len(devices) == 0 {
+		return IOThrottle{}, false
+	}
+	throttle := IOThrottle{Cgroup: group, Device: devices[len(devices)-1]}
+	root := cgroup2Mount()
+	weightSet, latencySet := false, false
+	for current := filepath.Clean("/" + group); ; current = filepath.Dir(current) {
+		directory := filepath.Join(root, current)
+		if data, err := os.ReadFile(filepath.Join(directory, "io.max")); err == nil {
+			rbps, wbps, riops, wiops := parseIOMax(string(data), devices)
+			throttle.ReadBPS, throttle.WriteBPS = minLimit(throttle.ReadBPS, rbps), minLimit(throttle.WriteBPS, wbps)
+			throttle.ReadIOPS, throttle.WriteIOPS = minLimit(throttle.ReadIOPS, riops), minLimit(throttle.WriteIOPS, wiops)
+		}
+		// 权重与延迟目标只对同级cgroup生效，取最内层的配置
+		if data, err := os.ReadFile(filepath.Join(directory, "io.weight")); err == nil && !weightSet {
+			throttle.Weight, weightSet = parseIOWeight(string(data), devices), true
+		}
+		if data, err := os.ReadFile(filepath.Join(directory, "io.latency")); err == nil && !latencySet {
+			if target := parseIOLatency(string(data), devices); target != 0 {
+				throttle.LatencyTargetUS, latencySet = target, true
+			}
+		}
+		if current == "/" {
+			break
+		}
+	}
+	return throttle, true
+}
+
+// throttleDevices 返回可能被配置限制的设备号：文件系统设备及其所在整盘
+func throttleDevices(deviceID, name string) []string {
+	var devices []string
+	if deviceID != "" {
+		devices = append(devices, deviceID)
+	}
+	if name == "" {
+		return devices
+	}
+	if disk := wholeDisk(name); disk != name {
+		if data, err := os.ReadFile(filepath.Join(sysfsRoot, "class", "block", disk, "dev")); err == nil {
+			devices = append(devices, strings.TrimSpace(string(data)))
+		}
+	}
+	return devices
+}
+
+// cgroup2Mount 混合模式下cgroup v2挂载在 unified 子目录
+func cgroup2Mount() string {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		unified := filepath.Join(cgroupRoot, "unified")
+		if _, err := os.Stat(filepath.Join(unified, "cgroup.controllers")); err == nil {
+			return unified
+		}
+	}
+	return cgroupRoot
+}
@@ -0,0 +1,34 @@
+package disk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectIOThrottleWalksHierarchy(t *testing.T) {
+	target := t.TempDir()
+	deviceID, _ := mountBlockDevice(target, "")
+	if deviceID == "" {
+		t.Skip("device number unavailable")
+	}
+	root := t.TempDir()
+	leaf := filepath.Join(root, "system.slice", "bench.service")
+	os.MkdirAll(leaf, 0o755)
+	os.WriteFile(filepath.Join(root, "system.slice", "io.max"), []byte(deviceID+" rbps=max wbps=1048576 riops=max wiops=max\n"), 0o644)
+	os.WriteFile(filepath.Join(leaf, "io.max"), []byte(deviceID+" rbps=max wbps=4194304 riops=300 wiops=max\n"), 0o644)
+	os.WriteFile(filepath.Join(leaf, "io.weight"), []byte("default 200\n"), 0o644)
+	cgroupFile := filepath.Join(root, "cgroup")
+	os.WriteFile(cgroupFile, []byte("0::/system.slice/bench.service\n"), 0o644)
+	previousRoot, previousFile := cgroupRoot, procSelfCgroup
+	cgroupRoot, procSelfCgroup = root, cgroupFile
+	defer func() { cgroupRoot, procSelfCgroup = previousRoot, previousFile }()
+	throttle, ok := DetectIOThrottle(target)
+	if !ok || throttle.WriteBPS != 1048576 || throttle.ReadIOPS != 300 || throttle.Weight != 200 || throttle.ReadBPS != 0 {
+		t.Fatalf("unexpected throttle %+v ok=%v", throttle, ok)
+	}
+	os.WriteFile(cgroupFile, []byte("1:name=systemd:/\n"), 0o644)
+	if _, ok := DetectIOThrottle(target); ok {
+		t.Fatal("cgroup v1 membership reported a throttle")
+	}
+}
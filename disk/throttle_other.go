@@ -0,0 +1,8 @@
+//go:build !linux
+
+package disk
+
+// detectIOThrottle cgroup v2 仅存在于Linux
+func detectIOThrottle(path string) (IOThrottle, bool) {
+	return IOThrottle{}, false
+}
@@ -0,0 +1,41 @@
+package disk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCgroupIOFiles(t *testing.T) {
+	devices := []string{"259:1", "259:0"}
+	rbps, wbps, riops, wiops := parseIOMax("8:0 rbps=1 wbps=1 riops=1 wiops=1\n259:0 rbps=max wbps=104857600 riops=max wiops=500\n", devices)
+	if rbps != 0 || wbps != 104857600 || riops != 0 || wiops != 500 {
+		t.Fatalf("unexpected io.max limits %d %d %d %d", rbps, wbps, riops, wiops)
+	}
+	if weight := parseIOWeight("default 100\n", devices); weight != 0 {
+		t.Fatalf("default weight reported as %d", weight)
+	}
+	if weight := parseIOWeight("default 100\n259:0 50\n", devices); weight != 50 {
+		t.Fatalf("device weight = %d, want 50", weight)
+	}
+	if target := parseIOLatency("259:0 target=2000\n", devices); target != 2000 {
+		t.Fatalf("latency target = %d, want 2000", target)
+	}
+	if minLimit(0, 10) != 10 || minLimit(10, 0) != 10 || minLimit(10, 5) != 5 {
+		t.Fatal("minLimit does not keep the tightest limit")
+	}
+}
+
+func TestIOThrottleWarnsNearLimit(t *testing.T) {
+	throttles := map[string]IOThrottle{"/data": {Cgroup: "/docker/x", Device: "259:0", WriteBPS: 100_000_000, ReadIOPS: 1000}}
+	checkThrottle(throttles, "/data", "write", 95_000_000, 10)
+	checkThrottle(throttles, "/data", "write", 96_000_000, 10)
+	checkThrottle(throttles, "/data", "read", 50_000_000, 500)
+	checkThrottle(throttles, "/other", "read", 1, 1)
+	if warnings := throttles["/data"].Warnings; len(warnings) != 1 || warnings[0] != "write_bps_near_limit" {
+		t.Fatalf("unexpected warnings %v", warnings)
+	}
+	text := renderThrottles("en", UnitFormat{}, throttles)
+	if !strings.Contains(text, "cgroup I/O limits on /data: wbps=100.00 MB/s riops=1000\n") || !strings.Contains(text, "Warning: the write result") {
+		t.Fatalf("unexpected rendering %q", text)
+	}
+}
@@ -0,0 +1,129 @@
+package disk
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// StorageLayer is one level of the block-device stack below a filesystem,
+// from the device holding the filesystem down to the physical disks.
+type StorageLayer struct {
+	// Kind is dm-crypt, lvm, multipath, dm or md for virtual layers and
+	// nvme, ssd, hdd, virtio, xen, mmc or loop for physical disks; disks of
+	// different kinds are joined with "+".
+	Kind string `json:"kind"`
+	// Level is the md RAID level such as raid1.
+	Level   string   `json:"level,omitempty"`
+	Devices []string `json:"devices"`
+}
+
+// StorageStack describes what a filesystem is built on. It is resolved from
+// /sys/block slaves on Linux and empty elsewhere.
+type StorageStack struct {
+	FsType string         `json:"fs_type,omitempty"`
+	Layers []StorageLayer `json:"layers,omitempty"`
+}
+
+// String renders the stack as in "ext4 → dm-crypt → md raid1 → 2×nvme".
+func (stack StorageStack) String() string {
+	parts := make([]string, 0, len(stack.Layers)+1)
+	if stack.FsType != "" {
+		parts = append(parts, stack.FsType)
+	}
+	for _, layer := range stack.Layers {
+		label := layer.Kind
+		if layer.Level != "" {
+			label += " " + layer.Level
+		}
+		if len(layer.Devices) > 1 {
+			label = fmt.Sprintf("%d×%s", len(layer.Devices), label)
+		}
+		parts = append(parts, label)
+	}
+	return strings.Join(parts, " → ")
+}
+
+// Layered reports whether the filesystem sits on a virtual device such as
+// LVM, dm-crypt or md RAID rather than directly on a disk or partition.
+func (stack StorageStack) Layered() bool {
+	return len(stack.Layers) > 1
+}
+
+// Members returns the device nodes of the physical disks at the bottom of
+// the stack.
+func (stack StorageStack) Members() []string {
+	if len(stack.Layers) == 0 {
+		return nil
+	}
+	devices := stack.Layers[len(stack.Layers)-1].Devices
+	members := make([]string, 0, len(devices))
+	for _, device := range devices {
+		members = append(members, "/dev/"+device)
+	}
+	return members
+}
+
+// ResolveStorageStack resolves the block-device stack of the filesystem
+// holding path.
+func ResolveStorageStack(path string) (StorageStack, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return StorageStack{}, err
+	}
+	parts, err := disk.Partitions(true)
+	if err != nil {
+		return StorageStack{}, err
+	}
+	var mount disk.PartitionStat
+	for _, part := range parts {
+		if isWithinMount(absolute, part.Mountpoint) && len(part.Mountpoint) >= len(mount.Mountpoint) {
+			mount = part
+		}
+	}
+	stack := storageStack(absolute, mount.Device)
+	stack.FsType = mount.Fstype
+	return stack, nil
+}
+
+// isWithinMount 判断路径是否位于挂载点之下
+func isWithinMount(path, mountPoint string) bool {
+	if mountPoint == "" {
+		return false
+	}
+	relative, err := filepath.Rel(mountPoint, path)
+	return err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
+
+// resolveStacks 返回测试路径中位于LVM、dm-crypt或md等虚拟设备上的存储栈
+func resolveStacks(paths []string) map[string]StorageStack {
+	var stacks map[string]StorageStack
+	for _, path := range paths {
+		stack, err := ResolveStorageStack(path)
+		if err != nil || !stack.Layered() {
+			continue
+		}
+		if stacks == nil {
+			stacks = make(map[string]StorageStack)
+		}
+		stacks[path] = stack
+	}
+	return stacks
+}
+
+// renderStorageStacks 按路径顺序输出存储栈说明
+func renderStorageStacks(language string, stacks map[string]StorageStack) string {
+	paths := make([]string, 0, len(stacks))
+	for path := range stacks {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var text string
+	for _, path := range paths {
+		text += localizedText(language, path+" 的存储栈: ", "Storage stack of "+path+": ") + stacks[path].String() + "\n"
+	}
+	return text
+}
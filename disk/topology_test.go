@@ -0,0 +1,43 @@
+package disk
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStorageStackString(t *testing.T) {
+	plain := StorageStack{FsType: "xfs", Layers: []StorageLayer{{Kind: "ssd", Devices: []string{"sda"}}}}
+	if plain.String() != "xfs → ssd" || plain.Layered() {
+		t.Fatalf("unexpected plain stack %q", plain.String())
+	}
+	lvm := StorageStack{FsType: "ext4", Layers: []StorageLayer{
+		{Kind: "lvm", Devices: []string{"dm-1"}},
+		{Kind: "hdd+ssd", Devices: []string{"sda", "sdb"}},
+	}}
+	if lvm.String() != "ext4 → lvm → 2×hdd+ssd" || !lvm.Layered() {
+		t.Fatalf("unexpected lvm stack %q", lvm.String())
+	}
+	text := renderStorageStacks("en", map[string]StorageStack{"/data": lvm})
+	if text != "Storage stack of /data: ext4 → lvm → 2×hdd+ssd\n" {
+		t.Fatalf("unexpected rendering %q", text)
+	}
+}
+
+func TestIsWithinMount(t *testing.T) {
+	root := string(filepath.Separator)
+	data := filepath.Join(root, "data")
+	for _, test := range []struct {
+		path, mount string
+		want        bool
+	}{
+		{filepath.Join(data, "x"), data, true},
+		{data, data, true},
+		{data + "2", data, false},
+		{data, root, true},
+		{data, "", false},
+	} {
+		if got := isWithinMount(test.path, test.mount); got != test.want {
+			t.Fatalf("isWithinMount(%q, %q) = %v", test.path, test.mount, got)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package disk
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// UnitSystem selects the prefixes used for rendered sizes and bandwidth.
+type UnitSystem string
+
+const (
+	// UnitsSI uses decimal prefixes: 1 MB = 1000 kB = 10^6 bytes.
+	UnitsSI UnitSystem = "si"
+	// UnitsIEC uses binary prefixes: 1 MiB = 1024 KiB = 2^20 bytes.
+	UnitsIEC UnitSystem = "iec"
+)
+
+// maxUnitPrecision bounds the number of decimals a UnitFormat may ask for.
+const maxUnitPrecision = 6
+
+// UnitFormat renders byte counts and rates for every human-readable output:
+// the FIO, DD and winsat tables, throttle summaries and the table report.
+// Typed and JSON results always keep raw bytes and bytes/s. The zero value
+// selects SI units with two decimals, as DefaultUnitFormat does.
+type UnitFormat struct {
+	System UnitSystem `json:"system"`
+	// Precision is the number of decimals, 0 through 6. It is only used
+	// when System is set.
+	Precision int `json:"precision"`
+}
+
+// DefaultUnitFormat returns SI units with two decimals.
+func DefaultUnitFormat() UnitFormat {
+	return UnitFormat{System: UnitsSI, Precision: 2}
+}
+
+// ParseUnitSystem accepts si (decimal) or iec (binary), case-insensitively.
+func ParseUnitSystem(value string) (UnitSystem, error) {
+	switch system := UnitSystem(strings.ToLower(strings.TrimSpace(value))); system {
+	case UnitsSI, UnitsIEC:
+		return system, nil
+	}
+	return "", fmt.Errorf("unit system must be si or iec")
+}
+
+// Validate reports an unknown unit system or a precision out of range.
+func (format UnitFormat) Validate() error {
+	if format.System == "" {
+		return nil
+	}
+	if _, err := ParseUnitSystem(string(format.System)); err != nil {
+		return err
+	}
+	if format.Precision < 0 || format.Precision > maxUnitPrecision {
+		return fmt.Errorf("unit precision must be between 0 and %d", maxUnitPrecision)
+	}
+	return nil
+}
+
+// resolved 未设置单位制时返回默认格式
+func (format UnitFormat) resolved() UnitFormat {
+	if format.System == "" {
+		return DefaultUnitFormat()
+	}
+	format.System = UnitSystem(strings.ToLower(string(format.System)))
+	return format
+}
+
+// Bytes renders a byte count such as "1.50 GB" or "1.40 GiB".
+func (format UnitFormat) Bytes(bytes float64) string {
+	format = format.resolved()
+	base, units := 1000.0, []string{"B", "kB", "MB", "GB", "TB", "PB"}
+	if format.System == UnitsIEC {
+		base, units = 1024.0, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	}
+	unit := 0
+	for math.Abs(bytes) >= base && unit < len(units)-1 {
+		bytes /= base
+		unit++
+	}
+	precision := format.Precision
+	if unit == 0 {
+		precision = 0
+	}
+	return strconv.FormatFloat(bytes, 'f', precision, 64) + " " + units[unit]
+}
+
+// Rate renders a bandwidth in bytes per second such as "2.10 GB/s".
+func (format UnitFormat) Rate(bytesPerSecond float64) string {
+	return format.Bytes(bytesPerSecond) + "/s"
+}
+
+// String names the unit system for output notes, such as "SI" or "IEC".
+func (format UnitFormat) String() string {
+	return strings.ToUpper(string(format.resolved().System))
+}
+
+// renderUnitNote 在表格末尾注明带宽与容量所用的单位制
+func renderUnitNote(language string, units UnitFormat) string {
+	return localizedText(language, "单位制: ", "Units: ") + units.String() + "\n"
+}
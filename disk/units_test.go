@@ -0,0 +1,69 @@
+package disk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestUnitFormatRate(t *testing.T) {
+	cases := []struct {
+		format UnitFormat
+		bps    float64
+		want   string
+	}{
+		{UnitFormat{}, 2_097_152_000, "2.10 GB/s"},
+		{UnitFormat{System: UnitsIEC, Precision: 2}, 2_097_152_000, "1.95 GiB/s"},
+		{UnitFormat{System: UnitsSI, Precision: 0}, 3_145_728, "3 MB/s"},
+		{UnitFormat{System: "IEC", Precision: 1}, 3_145_728, "3.0 MiB/s"},
+		{UnitFormat{}, 512, "512 B/s"},
+	}
+	for _, tc := range cases {
+		if got := tc.format.Rate(tc.bps); got != tc.want {
+			t.Fatalf("%+v.Rate(%v) = %q, want %q", tc.format, tc.bps, got, tc.want)
+		}
+	}
+}
+
+func TestUnitFormatValidate(t *testing.T) {
+	for _, format := range []UnitFormat{{}, {System: UnitsIEC, Precision: 6}} {
+		if err := format.Validate(); err != nil {
+			t.Fatalf("%+v: %v", format, err)
+		}
+	}
+	for _, format := range []UnitFormat{{System: "jedec"}, {System: UnitsSI, Precision: 7}, {System: UnitsSI, Precision: -1}} {
+		if err := format.Validate(); err == nil {
+			t.Fatalf("%+v was accepted", format)
+		}
+	}
+	if _, err := ParseUnitSystem("binary"); err == nil {
+		t.Fatal("unknown unit system was accepted")
+	}
+}
+
+func TestRenderersUseSelectedUnits(t *testing.T) {
+	iec := UnitFormat{System: UnitsIEC, Precision: 1}
+	fio := RenderFioLegacy("en", FioLegacyResult{Units: iec, Results: []FioBlockResult{{Path: "/data", BlockSize: "1m", TotalBytesPerSecond: 3 << 20, TotalIOPS: 3}}})
+	if !strings.Contains(fio, "3.0 MiB/s(3)") || !strings.Contains(fio, "Units: IEC\n") {
+		t.Fatalf("fio table ignored units: %q", fio)
+	}
+	measurement := DDMeasurement{Seconds: 1, BytesPerSecond: 100 << 20, IOPS: 100, ReportedSpeed: "105 MB/s"}
+	dd := RenderDDLegacy("en", DDLegacyResult{Units: iec, Results: []DDResult{{Device: "/data", BlockName: "100MB-1M Block", WriteStatus: "ok", ReadStatus: "ok", Write: measurement, Read: measurement}}})
+	if !strings.Contains(dd, "100.0 MiB/s(100.00 IOPS, 1.00s)") || strings.Contains(dd, "105 MB/s") {
+		t.Fatalf("dd table ignored units: %q", dd)
+	}
+	report := MatrixReport(fixtureMatrixResult())
+	report.Units = iec
+	var table bytes.Buffer
+	if err := WriteReport(&table, "table", report); err != nil || !strings.Contains(table.String(), "MiB/s") || !strings.Contains(table.String(), "单位制: IEC") {
+		t.Fatalf("table report ignored units: %v %q", err, table.String())
+	}
+	var csvOutput bytes.Buffer
+	if err := WriteReport(&csvOutput, "csv", report); err != nil || !strings.HasSuffix(strings.Split(csvOutput.String(), "\n")[1], ",IEC") {
+		t.Fatalf("csv report did not record units: %v %q", err, csvOutput.String())
+	}
+	winsat := parseWinsatOutput("> Disk  Sequential 64.0 Read                   512.00 MB/s          8.1\n", UnitFormat{})
+	if !strings.Contains(winsat, "536.87 MB/s[8.1]") {
+		t.Fatalf("winsat cell = %q", winsat)
+	}
+}
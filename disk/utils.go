@@ -14,7 +14,6 @@ import (
 	"unicode"
 
 	"github.com/mattn/go-runewidth"
-	"github.com/oneclickvirt/fio"
 	"go.uber.org/zap"
 )
 
@@ -103,6 +102,19 @@ func renderLegacyResults(language string, resultBlocks []string, header func(str
 	return header(language, paths) + strings.Join(nonEmpty, "")
 }
 
+// legacyResultStatus summarizes a typed legacy result: any parsed row makes
+// it usable, errors alongside rows make it partial.
+func legacyResultStatus(rows, errors int) string {
+	switch {
+	case rows == 0:
+		return "unavailable"
+	case errors > 0:
+		return "partial"
+	default:
+		return "ok"
+	}
+}
+
 func splitCommand(cmd string) []string {
 	parts := strings.Fields(strings.TrimSpace(cmd))
 	return parts
@@ -142,21 +154,54 @@ func isWritableMountpoint(path string) bool {
 		}
 		tempFile = filepath.Join(path, ".temp_write_check")
 	}
+	markArtifact(tempFile)
 	file, err := os.OpenFile(tempFile, os.O_WRONLY|os.O_CREATE, 0666)
 	if err != nil {
+		os.Remove(tempFile + artifactMarkerSuffix)
 		loggerInsert(Logger, "cannot open file for writing: "+err.Error())
 		return false
 	}
 	file.Close()
-	err = os.Remove(tempFile)
-	if err != nil {
+	// 先删探测文件再删标记，删除失败时残留的文件仍能被clean识别
+	if err := os.Remove(tempFile); err != nil {
 		loggerInsert(Logger, "cannot remove temporary file: "+err.Error())
+		return true
 	}
+	os.Remove(tempFile + artifactMarkerSuffix)
 	return true
 }
 
 func parseResultDD(tempText, blockCount string) string {
-	var result string
+	measurement, ok := parseDDMeasurement(tempText, blockCount)
+	if !ok {
+		return ""
+	}
+	return formatDDCell(measurement)
+}
+
+// formatDDCell 生成DD结果单元格文本，保持原有的列填充
+func formatDDCell(measurement DDMeasurement) string {
+	return fmt.Sprintf("%-30s", renderDDMeasurement(measurement, true, DefaultUnitFormat())) + "    "
+}
+
+// renderDDMeasurement 将DD测量结果按所选单位制渲染为 "22.43 MB/s(5.60K IOPS, 4.67s)" 形式
+func renderDDMeasurement(measurement DDMeasurement, ok bool, units UnitFormat) string {
+	if !ok {
+		return ""
+	}
+	var iopsText string
+	if measurement.IOPS >= 1000 {
+		iopsText = strconv.FormatFloat(measurement.IOPS/1000, 'f', 2, 64) + "K IOPS, " + strconv.FormatFloat(measurement.Seconds, 'f', 2, 64) + "s"
+	} else {
+		iopsText = strconv.FormatFloat(measurement.IOPS, 'f', 2, 64) + " IOPS, " + strconv.FormatFloat(measurement.Seconds, 'f', 2, 64) + "s"
+	}
+	return units.Rate(measurement.BytesPerSecond) + "(" + iopsText + ")"
+}
+
+// parseDDMeasurement 解析GNU/BusyBox/BSD格式的dd输出
+func parseDDMeasurement(tempText, blockCount string) (DDMeasurement, bool) {
+	var measurement DDMeasurement
+	var parsed bool
 	tp1 := strings.Split(tempText, "\n")
 	var records, usageTime float64
 	records, _ = strconv.ParseFloat(blockCount, 64)
@@ -175,22 +220,13 @@ func parseResultDD(tempText, blockCount string) string {
 					usageTime, _ = strconv.ParseFloat(timeStr, 64)
 					speedStr := strings.Split(timeAndSpeed[1], " ")[0]
 					speedFloat, _ := strconv.ParseFloat(speedStr, 64)
-					speedMBs := speedFloat / 1024 / 1024
-					var speedUnit string
-					if speedMBs >= 1024 {
-						speedMBs = speedMBs / 1024
-						speedUnit = "GB/s"
-					} else {
-						speedUnit = "MB/s"
+					measurement = DDMeasurement{
+						Bytes:          leadingDDBytes(t),
+						Seconds:        usageTime,
+						BytesPerSecond: speedFloat,
+						IOPS:           records / usageTime,
 					}
-					iops := records / usageTime
-					var iopsText string
-					if iops >= 1000 {
-						iopsText = strconv.FormatFloat(iops/1000, 'f', 2, 64) + "K IOPS, " + strconv.FormatFloat(usageTime, 'f', 2, 64) + "s"
-					} else {
-						iopsText = strconv.FormatFloat(iops, 'f', 2, 64) + " IOPS, " + strconv.FormatFloat(usageTime, 'f', 2, 64) + "s"
-					}
-					result += fmt.Sprintf("%-30s", fmt.Sprintf("%.2f %s(%s)", speedMBs, speedUnit, iopsText)) + "    "
+					parsed = true
 				}
 			}
 		} else if strings.Contains(t, "bytes") || strings.Contains(t, "字节") {
@@ -220,19 +256,48 @@ func parseResultDD(tempText, blockCount string) string {
 				}
 				ioSpeed, ioSpeedFlat, ok := splitSpeedAndUnit(speedStr)
 				if ok && usageTime > 0 {
-					iops := records / usageTime
-					var iopsText string
-					if iops >= 1000 {
-						iopsText = strconv.FormatFloat(iops/1000, 'f', 2, 64) + "K IOPS, " + strconv.FormatFloat(usageTime, 'f', 2, 64) + "s"
+					measurement = DDMeasurement{
+						Bytes:         leadingDDBytes(t),
+						Seconds:       usageTime,
+						IOPS:          records / usageTime,
+						ReportedSpeed: ioSpeed + " " + ioSpeedFlat,
+					}
+					if measurement.Bytes > 0 {
+						measurement.BytesPerSecond = float64(measurement.Bytes) / usageTime
 					} else {
-						iopsText = strconv.FormatFloat(iops, 'f', 2, 64) + " IOPS, " + strconv.FormatFloat(usageTime, 'f', 2, 64) + "s"
+						measurement.BytesPerSecond = ddSpeedBytesPerSecond(ioSpeed, ioSpeedFlat)
 					}
-					result += fmt.Sprintf("%-30s", ioSpeed+" "+ioSpeedFlat+"("+iopsText+")") + "    "
+					parsed = true
 				}
 			}
 		}
 	}
-	return result
+	return measurement, parsed
+}
+
+// leadingDDBytes 读取dd输出行开头的字节数
+func leadingDDBytes(line string) int64 {
+	end := strings.IndexFunc(line, func(r rune) bool { return !unicode.IsDigit(r) })
+	if end < 0 {
+		end = len(line)
+	}
+	value, _ := strconv.ParseInt(line[:end], 10, 64)
+	return value
+}
+
+// ddSpeedBytesPerSecond 将dd输出的速度和单位换算为bytes/s
+func ddSpeedBytesPerSecond(speed, unit string) float64 {
+	value, err := strconv.ParseFloat(strings.ReplaceAll(speed, ",", "."), 64)
+	if err != nil {
+		return 0
+	}
+	unit = strings.TrimSuffix(strings.TrimSpace(unit), "/s")
+	multipliers := map[string]float64{
+		"B": 1, "kB": 1e3, "KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+		"KiB": 1 << 10, "MiB": 1 << 20, "GiB": 1 << 30, "TiB": 1 << 40,
+		"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40,
+	}
+	return value * multipliers[unit]
 }
 
 func splitSpeedAndUnit(value string) (speed, unit string, ok bool) {
@@ -279,55 +344,12 @@ func formatIOPS(raw interface{}, rawType string) string {
 	}
 }
 
-func formatSpeed(raw interface{}, _ string) string {
-	var rawFloat float64
-	var err error
-	switch v := raw.(type) {
-	case string:
-		if v == "" {
-			return ""
-		}
-		rawFloat, err = strconv.ParseFloat(v, 64)
-		if err != nil {
-			return ""
-		}
-	case float64:
-		rawFloat = v
-	default:
-		return ""
-	}
-	var resultFloat float64 = rawFloat
-	var denom float64 = 1
-	unit := "KB/s"
-	if rawFloat >= 1000000 {
-		denom = 1000000
-		unit = "GB/s"
-	} else if rawFloat >= 1000 {
-		denom = 1000
-		unit = "MB/s"
-	}
-	resultFloat /= denom
-	result := fmt.Sprintf("%.2f", resultFloat)
-	return strings.Join([]string{result, unit}, " ")
-}
-
-func checkFioIOEngine() string {
+// checkFioIOEngine 依次探测可用的IO引擎，已知fio版本低于3.13时跳过io_uring
+func checkFioIOEngine(ctx context.Context, parts []string, info FioInfo) string {
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
 	}
-	embeddedCmd, embeddedPath, err := fio.GetFIO()
-	defer fio.CleanFio(embeddedPath)
-	if err == nil {
-		loggerInsert(Logger, "使用嵌入的fio二进制文件: "+embeddedPath)
-	} else {
-		loggerInsert(Logger, "无法获取嵌入的fio二进制文件: "+err.Error())
-		return "psync"
-	}
-	if embeddedCmd == "" {
-		return "psync"
-	}
-	parts := splitCommand(embeddedCmd)
 	if len(parts) == 0 {
 		loggerInsert(Logger, "fio命令为空，使用psync")
 		return "psync"
@@ -350,10 +372,15 @@ func checkFioIOEngine() string {
 		}
 		tempFile = filepath.Join(tempDir, "fio_engine_check")
 	}
+	markArtifact(tempFile)
+	defer removeArtifact(tempFile)
 	engines := []string{}
 	switch runtime.GOOS {
 	case "linux":
-		engines = []string{"io_uring", "libaio", "posixaio"}
+		engines = []string{"libaio", "posixaio"}
+		if info.mayUseIOUring() {
+			engines = append([]string{"io_uring"}, engines...)
+		}
 	case "darwin":
 		engines = []string{"posixaio"}
 	case "windows":
@@ -362,12 +389,11 @@ func checkFioIOEngine() string {
 		engines = []string{"posixaio"}
 	}
 	for _, engine := range engines {
-		cmd := exec.Command(parts[0], append(parts[1:], "--name=check", "--ioengine="+engine, "--runtime=1", "--size=1M", "--direct=1", "--filename="+tempFile, "--minimal")...)
-		_, err = cmd.CombinedOutput()
-		defer func(filename string) {
-			os.Remove(filename)
-		}(tempFile)
-		if err == nil {
+		if ctx.Err() != nil {
+			break
+		}
+		cmd := benchmarkCommand(ctx, parts[0], append(parts[1:], "--name=check", "--ioengine="+engine, "--runtime=1", "--size=1M", "--direct=1", "--filename="+tempFile, "--minimal")...)
+		if _, err := cmd.CombinedOutput(); err == nil {
 			loggerInsert(Logger, engine+" IO引擎可用")
 			return engine
 		}
@@ -402,6 +428,39 @@ func getDefaultTestPaths() (string, string) {
 	return rootPath, tmpPath
 }
 
+// parseSizeBytes 解析 "512M"、"2G"、"1.5G" 形式的大小，后缀按1024进制计算
+func parseSizeBytes(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(value), "B"), "I")
+	multiplier := float64(1)
+	if trimmed != "" {
+		switch trimmed[len(trimmed)-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		case 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier > 1 {
+			trimmed = trimmed[:len(trimmed)-1]
+		}
+	}
+	number, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("invalid size: %s", value)
+	}
+	return uint64(number * multiplier), nil
+}
+
+// ParseSize parses sizes such as "512M", "2G" or "1.5T" with binary
+// multipliers and returns the number of bytes.
+func ParseSize(value string) (uint64, error) {
+	return parseSizeBytes(value)
+}
+
 // ensurePathExists 确保路径存在，如果不存在则创建
 func ensurePathExists(path string) error {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
@@ -3,6 +3,7 @@ package disk
 import (
 	"fmt"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"github.com/shirou/gopsutil/disk"
@@ -10,6 +11,10 @@ import (
 
 // WinsatTest 通过windows自带系统工具测试IO
 func WinsatTest(language string, enableMultiCheck bool, testPath string) string {
+	return RunTest(TestOptions{Method: "winsat", Language: language, MultiCheck: enableMultiCheck, Paths: []string{testPath}}).Text
+}
+
+func runWinsat(language string, units UnitFormat, enableMultiCheck bool, testPath string) string {
 	var result string
 	parts, err := disk.Partitions(true)
 	if err == nil {
@@ -22,22 +27,25 @@ func WinsatTest(language string, enableMultiCheck bool, testPath string) string
 			if enableMultiCheck {
 				for _, f := range parts {
 					result += fmt.Sprintf("%-20s", f.Device) + "    "
-					result += getDiskPerformance(f.Device)
+					result += getDiskPerformance(f.Device, units)
 				}
 			} else {
 				result += fmt.Sprintf("%-20s", "C:") + "    "
-				result += getDiskPerformance("C:")
+				result += getDiskPerformance("C:", units)
 			}
 		} else {
 			result += fmt.Sprintf("%-20s", testPath) + "    "
-			result += getDiskPerformance(testPath)
+			result += getDiskPerformance(testPath, units)
 		}
 	}
 	return result
 }
 
+// winsatTests winsat输出中对应随机读取、顺序读取与顺序写入的行前缀
+var winsatTests = []string{"> Disk  Random 16.0 Read", "> Disk  Sequential 64.0 Read", "> Disk  Sequential 64.0 Write"}
+
 // getDiskPerformance 获取WIN的硬盘性能数据
-func getDiskPerformance(device string) string {
+func getDiskPerformance(device string, units UnitFormat) string {
 	if EnableLoger {
 		InitLogger()
 		defer Logger.Sync()
@@ -48,38 +56,35 @@ func getDiskPerformance(device string) string {
 		loggerInsert(Logger, "cannot match winsat command: "+err.Error())
 		return ""
 	}
+	return parseWinsatOutput(string(output), units)
+}
+
+// parseWinsatOutput 按所选单位制重新格式化winsat输出的速度与得分
+func parseWinsatOutput(output string, units UnitFormat) string {
 	var result string
-	tempList := strings.Split(string(output), "\n")
-	for _, l := range tempList {
-		if strings.Contains(l, "> Disk  Random 16.0 Read") {
-			// 随机读取速度
-			tempText := strings.TrimSpace(strings.ReplaceAll(l, "> Disk  Random 16.0 Read", ""))
-			if tempText != "" {
-				tpList := strings.Split(tempText, "MB/s")
-				if len(tpList) >= 2 {
-					result += fmt.Sprintf("%-20s", strings.TrimSpace(tpList[0]+"MB/s["+strings.TrimSpace(tpList[len(tpList)-1])+"]")) + "    "
-				}
-			}
-		} else if strings.Contains(l, "> Disk  Sequential 64.0 Read") {
-			// 顺序读取速度
-			tempText := strings.TrimSpace(strings.ReplaceAll(l, "> Disk  Sequential 64.0 Read", ""))
-			if tempText != "" {
-				tpList := strings.Split(tempText, "MB/s")
-				if len(tpList) >= 2 {
-					result += fmt.Sprintf("%-20s", strings.TrimSpace(tpList[0]+"MB/s["+strings.TrimSpace(tpList[len(tpList)-1])+"]")) + "    "
-				}
+	for _, l := range strings.Split(output, "\n") {
+		for _, prefix := range winsatTests {
+			if !strings.Contains(l, prefix) {
+				continue
 			}
-		} else if strings.Contains(l, "> Disk  Sequential 64.0 Write") {
-			// 顺序写入速度
-			tempText := strings.TrimSpace(strings.ReplaceAll(l, "> Disk  Sequential 64.0 Write", ""))
-			if tempText != "" {
-				tpList := strings.Split(tempText, "MB/s")
-				if len(tpList) >= 2 {
-					result += fmt.Sprintf("%-20s", strings.TrimSpace(tpList[0]+"MB/s["+strings.TrimSpace(tpList[len(tpList)-1])+"]")) + "    "
-				}
+			if cell, ok := formatWinsatCell(strings.TrimSpace(strings.ReplaceAll(l, prefix, "")), units); ok {
+				result += fmt.Sprintf("%-20s", cell) + "    "
 			}
 		}
 	}
 	result += "\n"
 	return result
 }
+
+// formatWinsatCell 将 "520.32 MB/s          7.9" 转换为 "520.32 MB/s[7.9]"，winsat的MB按1024换算
+func formatWinsatCell(text string, units UnitFormat) (string, bool) {
+	speed, score, found := strings.Cut(text, "MB/s")
+	if !found {
+		return "", false
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(speed), 64)
+	if err != nil {
+		return "", false
+	}
+	return units.Rate(value*(1<<20)) + "[" + strings.TrimSpace(score) + "]", true
+}
@@ -9,6 +9,7 @@ require (
 	github.com/oneclickvirt/fio v0.0.2-20250808045755
 	github.com/shirou/gopsutil v3.21.11+incompatible
 	go.uber.org/zap v1.27.0
+	golang.org/x/sys v0.20.0
 )
 
 require (
@@ -16,5 +17,4 @@ require (
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
-	golang.org/x/sys v0.20.0 // indirect
 )